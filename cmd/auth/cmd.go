@@ -0,0 +1,203 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jmrodri/gh2jira/cmd/global"
+	"github.com/jmrodri/gh2jira/internal/config"
+	"github.com/jmrodri/gh2jira/internal/gh"
+	"github.com/jmrodri/gh2jira/internal/jira"
+	"github.com/jmrodri/gh2jira/internal/redact"
+	"github.com/jmrodri/gh2jira/internal/token"
+)
+
+var (
+	tokenFile string
+	githubURL string
+	project   string
+)
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Check the Github and Jira credentials",
+	}
+
+	cmd.AddCommand(newStatusCmd())
+
+	return cmd
+}
+
+func newStatusCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Check that the tokens work and have the needed access",
+		Long: `Check the Github token, its scopes and rate limit, and the Jira token
+and its permissions in the Jira project. Exits with a non-zero status if a
+check fails.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			printer, err := gh.NewPrinter(os.Stdout, global.ColorMode())
+			if err != nil {
+				return err
+			}
+			profile, err := global.Profile()
+			if err != nil {
+				return err
+			}
+
+			heading := "Github https://github.com"
+			if githubURL != "" {
+				heading = "Github " + githubURL
+			}
+			githubOK, err := printer.PrintChecks(heading, githubChecks())
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(redact.Stdout)
+			jiraOK, err := printer.PrintChecks(
+				fmt.Sprintf("Jira %s (%s auth)", profile.JiraURL, profile.JiraAuth), jiraChecks())
+			if err != nil {
+				return err
+			}
+
+			if !githubOK || !jiraOK {
+				// the checklist says it all
+				cmd.SilenceUsage = true
+				return errors.New("some checks failed")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&tokenFile, "token-file", config.DefaultTokenFile,
+		"file containing github and jira tokens")
+	cmd.Flags().StringVar(&githubURL, "github-url", "",
+		"URL of a Github Enterprise Server, e.g. https://github.example.com")
+	cmd.Flags().StringVar(&project, "project", config.DefaultJiraProject,
+		"Jira project to check the permissions in")
+	global.BindProfile(cmd.Flags(), map[string]string{
+		"token-file": "tokenFile",
+		"github-url": "githubURL",
+		"project":    "jiraProject",
+	})
+
+	return cmd
+}
+
+func githubChecks() []gh.Check {
+	tokens, err := global.Tokens(tokenFile, token.Github)
+	if err != nil {
+		return []gh.Check{{Name: "token", State: gh.CheckFail, Detail: err.Error()}}
+	}
+	info, err := gh.GetTokenInfo(
+		gh.WithToken(tokens.GithubToken),
+		gh.WithGithubURL(githubURL),
+		gh.WithTransport(global.GithubTransport()),
+	)
+	if err != nil {
+		return []gh.Check{{Name: "token", State: gh.CheckFail, Detail: err.Error()}}
+	}
+
+	checks := []gh.Check{{
+		Name:   "token",
+		State:  gh.CheckPass,
+		Detail: "authenticated as " + info.User.GetLogin(),
+	}}
+
+	scopes := gh.Check{Name: "scopes", State: gh.CheckPass, Detail: strings.Join(info.Scopes, ", ")}
+	switch {
+	case info.Scopes == nil:
+		scopes.State = gh.CheckWarn
+		scopes.Detail = "not reported, e.g. for a fine-grained token"
+	case !hasAny(info.Scopes, "repo", "public_repo"):
+		scopes.State = gh.CheckFail
+		scopes.Detail = "missing the repo or public_repo scope, has: " + strings.Join(info.Scopes, ", ")
+		if len(info.Scopes) == 0 {
+			scopes.Detail += "none"
+		}
+	}
+	checks = append(checks, scopes)
+
+	rate := gh.Check{
+		Name:  "rate limit",
+		State: gh.CheckPass,
+		Detail: fmt.Sprintf("%d of %d requests left, resets at %s", info.Rate.Remaining, info.Rate.Limit,
+			info.Rate.Reset.Local().Format("15:04")),
+	}
+	if info.Rate.Remaining == 0 {
+		rate.State = gh.CheckFail
+	}
+	return append(checks, rate)
+}
+
+func jiraChecks() []gh.Check {
+	tokens, err := global.Tokens(tokenFile, token.Jira)
+	if err != nil {
+		return []gh.Check{{Name: "token", State: gh.CheckFail, Detail: err.Error()}}
+	}
+	opts := []jira.Option{
+		jira.WithToken(tokens.JiraToken),
+		global.JiraServer(),
+		jira.WithTransport(global.JiraTransport()),
+	}
+	me, err := jira.Myself(opts...)
+	if err != nil {
+		return []gh.Check{{Name: "token", State: gh.CheckFail, Detail: err.Error()}}
+	}
+
+	// Jira Cloud users have no name, only an email address
+	user := me.Name
+	if user == "" {
+		user = me.EmailAddress
+	}
+	checks := []gh.Check{{
+		Name:   "token",
+		State:  gh.CheckPass,
+		Detail: fmt.Sprintf("authenticated as %s (%s)", me.DisplayName, user),
+	}}
+
+	have, err := jira.MyPermissions(project, jira.RequiredPermissions, opts...)
+	if err != nil {
+		return append(checks, gh.Check{Name: "project " + project, State: gh.CheckFail, Detail: err.Error()})
+	}
+	for _, p := range jira.RequiredPermissions {
+		c := gh.Check{Name: p, State: gh.CheckPass, Detail: "granted in " + project}
+		if !have[p] {
+			c.State = gh.CheckFail
+			c.Detail = "missing in " + project
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
+
+func hasAny(values []string, wanted ...string) bool {
+	for _, v := range values {
+		for _, w := range wanted {
+			if v == w {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -15,62 +15,250 @@
 package clone
 
 import (
-	"strconv"
+	"errors"
+	"fmt"
 
+	"github.com/google/go-github/v47/github"
 	"github.com/spf13/cobra"
 
+	"github.com/jmrodri/gh2jira/cmd/global"
+	"github.com/jmrodri/gh2jira/internal/config"
 	"github.com/jmrodri/gh2jira/internal/gh"
 	"github.com/jmrodri/gh2jira/internal/jira"
+	"github.com/jmrodri/gh2jira/internal/mapping"
+	"github.com/jmrodri/gh2jira/internal/redact"
 	"github.com/jmrodri/gh2jira/internal/token"
 )
 
 var (
-	dryRun    bool
-	project   string
-	ghproject string
-	tokenFile string
+	githubURL   string
+	dryRun      bool
+	project     string
+	ghproject   string
+	tokenFile   string
+	mappingFile string
+	apiVersion  int
+	comments    bool
+	query       string
+
+	label        []string
+	anyLabel     bool
+	excludeLabel []string
+	noAssignee   bool
+	noMilestone  bool
+
+	sensitiveLabels   []string
+	securityLevel     string
+	restrictedProject string
+	allowSensitive    bool
+
+	watchers        []string
+	watchAssignees  bool
+	watchCommenters bool
+	userMap         map[string]string
 )
 
 func NewCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "clone <ISSUE_ID> [ISSUE_ID ...]",
+		Use:   "clone [ISSUE ...]",
 		Short: "Clone given Github issues to Jira",
-		Long:  `Clone given Github issues to Jira.
-WARNING! This will write to your jira instance. Use --dryrun to see what will happen`,
-		Args:  cobra.MinimumNArgs(1),
+		Long: `Clone given Github issues to Jira.
+WARNING! This will write to your jira instance. Use --dryrun to see what will happen
+
+The issues are given by number, ORG/REPO#NUMBER or URL, issues of other
+projects than --github-project can be mixed in, and/or selected with a Github
+search --query. Github search returns at most 1000 issues, narrow the query
+down if it matches more.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 && query == "" {
+				return errors.New("requires at least 1 issue or a --query")
+			}
+			return nil
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			tokens, err := token.ReadTokensYaml(tokenFile)
+			tokens, err := global.Tokens(tokenFile, token.Github, token.Jira)
 			if err != nil {
 				return err
 			}
-			for _, id := range args {
-				issueId, _ := strconv.Atoi(id)
-				issue, err := gh.GetIssue(issueId,
-					gh.WithToken(tokens.GithubToken),
-					gh.WithProject(ghproject),
-				)
+			store, err := mapping.Open(mappingFile)
+			if err != nil {
+				return err
+			}
+			ghopts := []gh.Option{
+				gh.WithToken(tokens.GithubToken),
+				gh.WithTransport(global.GithubTransport()),
+				gh.WithGithubURL(githubURL),
+				gh.WithProject(ghproject),
+				// only apply to the issues matching --query
+				gh.WithLabel(label),
+				gh.WithAnyLabel(anyLabel),
+				gh.WithExcludeLabel(excludeLabel),
+				gh.WithNoAssignee(noAssignee),
+				gh.WithNoMilestone(noMilestone),
+			}
+			var issues []*github.Issue
+			if query != "" {
+				issues, err = gh.SearchIssues(query, ghopts...)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(redact.Stdout, "Query matched %d issues\n", len(issues))
+				// unlike issues given by number, don't clone matches twice
+				issues, err = skipCloned(store, issues)
 				if err != nil {
 					return err
 				}
+			}
+			for _, ref := range args {
+				issue, err := gh.GetIssueRef(ref, ghopts...)
+				if err != nil {
+					return err
+				}
+				issues = append(issues, issue)
+			}
+			// clone what can be, then fail if any issue could not be
+			var failed int
+			fail := func(issue *github.Issue, err error) {
+				org, repo := gh.IssueRepo(issue)
+				fmt.Fprintf(redact.Stderr, "Error: %s/%s#%d: %v\n", org, repo, issue.GetNumber(), err)
+				failed++
+			}
+			for _, issue := range issues {
+				if issue.IsPullRequest() {
+					// We have a PR, skipping
+					continue
+				}
+				var participants []string
+				var ghcomments []*github.IssueComment
+				if watchCommenters || comments {
+					opts := ghopts
+					if org, repo := gh.IssueRepo(issue); org != "" {
+						// search results can come from other repos
+						opts = append(opts[:len(opts):len(opts)], gh.WithProject(org+"/"+repo))
+					}
+					ghcomments, err = gh.ListComments(issue.GetNumber(), opts...)
+					if err != nil {
+						fail(issue, err)
+						continue
+					}
+				}
+				if watchCommenters {
+					for _, c := range ghcomments {
+						participants = append(participants, c.GetUser().GetLogin())
+					}
+				}
+				if !comments {
+					ghcomments = nil
+				}
 				_, err = jira.Clone(issue,
 					jira.WithToken(tokens.JiraToken),
+					global.JiraServer(),
+					jira.WithTransport(global.JiraTransport()),
 					jira.WithProject(project),
 					jira.WithDryRun(dryRun),
+					jira.WithAPIVersion(apiVersion),
+					jira.WithComments(ghcomments),
+					jira.WithMappingStore(store),
+					jira.WithSensitivePolicy(&jira.SensitivePolicy{
+						Labels:         sensitiveLabels,
+						SecurityLevel:  securityLevel,
+						Project:        restrictedProject,
+						AllowSensitive: allowSensitive,
+					}),
+					jira.WithWatchers(watchers),
+					jira.WithWatchAssignees(watchAssignees),
+					jira.WithParticipants(participants),
+					jira.WithUserMap(userMap),
 				)
 				if err != nil {
-					return nil
+					// e.g. refused by the sensitive issue policy, or cloned but
+					// not recorded so that --query would clone it again
+					fail(issue, err)
 				}
 			}
+			if failed > 0 {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%d of %d issues could not be cloned", failed, len(issues))
+			}
 			return nil
 		},
 	}
 
-	cmd.Flags().StringVar(&tokenFile, "token-file", "tokens.yaml",
+	cmd.Flags().StringVar(&tokenFile, "token-file", config.DefaultTokenFile,
 		"file containing github and jira tokens")
+	cmd.Flags().StringVar(&githubURL, "github-url", "",
+		"URL of a Github Enterprise Server, e.g. https://github.example.com")
 	cmd.Flags().BoolVar(&dryRun, "dryrun", false, "display what we would do without cloning")
-	cmd.Flags().StringVar(&project, "project", "OSDK", "Jira project to clone to")
-	cmd.Flags().StringVar(&ghproject, "github-project", "operator-framework/operator-sdk",
+	cmd.Flags().StringVar(&project, "project", config.DefaultJiraProject, "Jira project to clone to")
+	cmd.Flags().StringVar(&ghproject, "github-project", config.DefaultGithubProject,
 		"Github project to clone from e.g. ORG/REPO")
+	cmd.Flags().StringVar(&mappingFile, "mapping-file", "",
+		"mapping store file (default $XDG_STATE_HOME/gh2jira/mapping.json)")
+	cmd.Flags().StringVar(&query, "query", "",
+		"clone the issues matching the github issue search query, at most 1000")
+	cmd.Flags().StringSliceVar(&label, "label", nil,
+		"with --query, only issues with these labels i.e. --label \"documentation,bug\" or --label doc --label bug")
+	cmd.Flags().BoolVar(&anyLabel, "any-label", false,
+		"with --query, issues with any of the --label labels instead of all of them")
+	cmd.Flags().StringSliceVar(&excludeLabel, "exclude-label", nil,
+		"with --query, skip issues with any of these labels i.e. --exclude-label lifecycle/stale")
+	cmd.Flags().BoolVar(&noAssignee, "no-assignee", false, "with --query, only issues nobody is assigned to")
+	cmd.Flags().BoolVar(&noMilestone, "no-milestone", false, "with --query, only issues without a milestone")
+	cmd.Flags().IntVar(&apiVersion, "jira-api-version", jira.APIv2,
+		"jira REST api version, 3 sends Atlassian Document Format to Jira Cloud")
+	cmd.Flags().BoolVar(&comments, "with-comments", false,
+		"copy the github comments to the jira issue")
+	cmd.Flags().StringSliceVar(&sensitiveLabels, "sensitive-label", jira.DefaultSensitiveLabels,
+		"github labels marking an issue as sensitive, may be globs")
+	cmd.Flags().StringVar(&securityLevel, "security-level", "",
+		"jira security level to set on sensitive issues")
+	cmd.Flags().StringVar(&restrictedProject, "restricted-project", "",
+		"jira project to clone sensitive issues to")
+	cmd.Flags().BoolVar(&allowSensitive, "allow-sensitive", false,
+		"clone sensitive issues even without a security level or restricted project")
+	cmd.Flags().StringSliceVar(&watchers, "watcher", nil,
+		"jira user to add as a watcher of every clone, may be repeated")
+	cmd.Flags().BoolVar(&watchAssignees, "watch-assignees", false,
+		"add the jira users of the github assignees as watchers")
+	cmd.Flags().BoolVar(&watchCommenters, "watch-commenters", false,
+		"add the jira users of the github commenters as watchers")
+	cmd.Flags().StringToStringVar(&userMap, "user-map", nil,
+		"map github logins to jira users i.e. --user-map ghlogin=jirauser")
+	global.BindProfile(cmd.Flags(), map[string]string{
+		"token-file":         "tokenFile",
+		"github-url":         "githubURL",
+		"project":            "jiraProject",
+		"github-project":     "githubProject",
+		"mapping-file":       "mappingFile",
+		"jira-api-version":   "jiraAPIVersion",
+		"sensitive-label":    "sensitiveLabels",
+		"security-level":     "securityLevel",
+		"restricted-project": "restrictedProject",
+		"watcher":            "watchers",
+		"user-map":           "userMap",
+		"any-label":          "anyLabel",
+		"exclude-label":      "excludeLabels",
+		"no-assignee":        "noAssignee",
+		"no-milestone":       "noMilestone",
+	})
 
 	return cmd
 }
+
+// skipCloned drops the issues already recorded in the mapping store.
+func skipCloned(store *mapping.Store, issues []*github.Issue) ([]*github.Issue, error) {
+	var uncloned []*github.Issue
+	for _, issue := range issues {
+		org, repo := gh.IssueRepo(issue)
+		rec, err := store.Get(org, repo, issue.GetNumber())
+		if err != nil {
+			return nil, err
+		}
+		if rec != nil {
+			fmt.Fprintf(redact.Stdout, "Skipping %s, already cloned to %s\n", rec.ID(), rec.JiraKey)
+			continue
+		}
+		uncloned = append(uncloned, issue)
+	}
+	return uncloned, nil
+}
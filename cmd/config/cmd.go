@@ -0,0 +1,63 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jmrodri/gh2jira/cmd/global"
+)
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the gh2jira configuration",
+		Long: `Inspect the configuration file and its profiles. Run genconfig to
+create one.`,
+	}
+
+	cmd.AddCommand(newViewCmd())
+
+	return cmd
+}
+
+func newViewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "view",
+		Short: "Print the configuration in effect",
+		Long: `Print the settings in effect for the selected profile: the built-in
+defaults replaced by the profile, replaced by the global flags. Flags of the
+other commands override these settings as well.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p, err := global.Profile()
+			if err != nil {
+				return err
+			}
+			data, err := yaml.Marshal(p)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "# %s, profile %s\n%s",
+				global.ConfigFile(), global.ProfileName(), data)
+			return nil
+		},
+	}
+
+	return cmd
+}
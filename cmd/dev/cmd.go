@@ -0,0 +1,121 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dev
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	gojira "github.com/andygrunwald/go-jira"
+	"github.com/spf13/cobra"
+
+	"github.com/jmrodri/gh2jira/internal/config"
+	"github.com/jmrodri/gh2jira/internal/httpserver"
+	"github.com/jmrodri/gh2jira/internal/jira/mock"
+	"github.com/jmrodri/gh2jira/internal/redact"
+)
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dev",
+		Short: "Tools for developing gh2jira",
+		Long: `Tools for developing and testing gh2jira without touching real Github or
+Jira instances.`,
+	}
+
+	cmd.AddCommand(newFakeJiraCmd())
+
+	return cmd
+}
+
+func newFakeJiraCmd() *cobra.Command {
+	var (
+		addr     string
+		projects []string
+		users    []string
+		token    string
+		quiet    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "fake-jira",
+		Short: "Run an in-memory fake Jira server",
+		Long: `Run an in-memory Jira server to develop and try gh2jira against. It
+keeps the issues, comments, links, watchers, transitions and attachments
+created until it stops, and answers JQL searches on them.
+
+A project is given as KEY or KEY=Name. The first user is the one
+authenticated. Without --token any token is accepted.
+
+Point the other commands at it with --jira-url, e.g.
+
+  gh2jira dev fake-jira --addr 127.0.0.1:8080 &
+  gh2jira clone 123 --jira-url http://127.0.0.1:8080 --jira-token anything`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts := []mock.FakeOption{mock.WithFakeToken(token)}
+			var keys []string
+			for _, p := range projects {
+				key, name, _ := strings.Cut(p, "=")
+				if key == "" {
+					return fmt.Errorf("invalid project %q, must be KEY or KEY=Name", p)
+				}
+				if name == "" {
+					name = key
+				}
+				opts = append(opts, mock.WithFakeProject(key, name))
+				keys = append(keys, key)
+			}
+			for _, u := range users {
+				opts = append(opts, mock.WithFakeUser(gojira.User{
+					Name:         u,
+					DisplayName:  u,
+					EmailAddress: u + "@example.com",
+				}))
+			}
+			var log io.Writer = redact.Stdout
+			if quiet {
+				log = io.Discard
+			}
+			opts = append(opts, mock.WithFakeRequestLog(log))
+			redact.Add(token)
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			fmt.Fprintf(redact.Stdout, "Fake jira listening on %s with projects %s\n",
+				addr, strings.Join(keys, ", "))
+			err := httpserver.Serve(ctx, addr, mock.NewFake(opts...), 5*time.Second)
+			fmt.Fprintln(redact.Stdout, "Fake jira stopped, its issues are gone")
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&addr, "addr", "127.0.0.1:8080", "address to listen on")
+	cmd.Flags().StringSliceVar(&projects, "project", []string{config.DefaultJiraProject},
+		"project to create, KEY or KEY=Name, may be repeated")
+	cmd.Flags().StringSliceVar(&users, "user", nil,
+		"user to create, the first one is authenticated, may be repeated (default gh2jira)")
+	cmd.Flags().StringVar(&token, "token", "", "token to require, any token is accepted if empty")
+	cmd.Flags().BoolVar(&quiet, "quiet", false, "do not log the requests")
+
+	return cmd
+}
@@ -0,0 +1,274 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genconfig
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jmrodri/gh2jira/cmd/global"
+	"github.com/jmrodri/gh2jira/internal/config"
+	"github.com/jmrodri/gh2jira/internal/gh"
+	"github.com/jmrodri/gh2jira/internal/jira"
+	"github.com/jmrodri/gh2jira/internal/token"
+)
+
+var (
+	nonInteractive bool
+	force          bool
+	skipValidation bool
+	githubProject  string
+	githubURL      string
+	project        string
+)
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "genconfig",
+		Aliases: []string{"init"},
+		Short:   "Create the configuration and token files",
+		Long: `Ask for the Github project, the Jira server and project, how to
+authenticate to Jira and the tokens, check the tokens work and write the
+settings to the --profile profile of the configuration file and the tokens to
+a token file next to it.
+
+With --non-interactive the settings are taken from the flags, and the tokens
+from --github-token/--jira-token, the environment or the credential helper.`,
+		Args: cobra.NoArgs,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return global.LoadProfile(cmd.Flags(), true)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configPath := global.ConfigFile()
+			name := global.ProfileName()
+			tokenPath := filepath.Join(filepath.Dir(configPath), config.DefaultTokenFile)
+			if name != config.DefaultProfile {
+				tokenPath = filepath.Join(filepath.Dir(configPath), "tokens-"+name+".yaml")
+			}
+
+			// refuse early rather than after all the questions
+			c, err := config.Load(configPath)
+			if err != nil {
+				return err
+			}
+			if _, ok := c.Profiles[name]; ok && !force {
+				return fmt.Errorf("profile %s already exists in %s, use --force to replace it", name, configPath)
+			}
+			if _, err := os.Stat(tokenPath); err == nil && !force {
+				return fmt.Errorf("%s already exists, use --force to overwrite it", tokenPath)
+			}
+
+			// start from the settings in effect to keep the rest of an
+			// existing profile
+			profile, err := global.Profile()
+			if err != nil {
+				return err
+			}
+			profile.GithubProject = githubProject
+			profile.GithubURL = githubURL
+			profile.JiraProject = project
+
+			tokens := defaultTokens()
+			if !nonInteractive {
+				if err := ask(cmd.InOrStdin(), cmd.OutOrStdout(), profile, tokens); err != nil {
+					return err
+				}
+			}
+			if profile.JiraAuth == jira.AuthBearer {
+				profile.JiraUser = ""
+			}
+			if err := profile.Validate(); err != nil {
+				return err
+			}
+			if profile.JiraProject == "" {
+				return errors.New("missing jira project")
+			}
+			if tokens.GithubToken == "" {
+				return errors.New("missing github token")
+			}
+			if tokens.JiraToken == "" {
+				return errors.New("missing jira token")
+			}
+
+			if !skipValidation {
+				if err := validate(cmd.OutOrStdout(), profile, tokens); err != nil {
+					return err
+				}
+			}
+
+			data, err := yaml.Marshal(tokens)
+			if err != nil {
+				return err
+			}
+			if err := config.WriteFile(tokenPath, data, force); errors.Is(err, fs.ErrExist) {
+				return fmt.Errorf("%w, use --force to overwrite it", err)
+			} else if err != nil {
+				return err
+			}
+			// the other profiles are kept
+			profile.TokenFile = tokenPath
+			c.Profiles[name] = profile
+			if err := c.Write(configPath, true); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Wrote profile %s to %s and the tokens to %s\n", name, configPath, tokenPath)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&nonInteractive, "non-interactive", false,
+		"do not ask, take the settings from the flags")
+	cmd.Flags().BoolVar(&force, "force", false, "overwrite existing files")
+	cmd.Flags().BoolVar(&skipValidation, "skip-validation", false,
+		"do not check the tokens against Github and Jira")
+	cmd.Flags().StringVar(&githubProject, "github-project", config.DefaultGithubProject,
+		"Github project to clone from, ORG/REPO")
+	cmd.Flags().StringVar(&githubURL, "github-url", "",
+		"Github Enterprise Server URL, e.g. https://github.example.com (default github.com)")
+	cmd.Flags().StringVar(&project, "project", config.DefaultJiraProject, "Jira project to clone to")
+	global.BindProfile(cmd.Flags(), map[string]string{
+		"github-project": "githubProject",
+		"github-url":     "githubURL",
+		"project":        "jiraProject",
+	})
+
+	return cmd
+}
+
+// defaultTokens returns the tokens already given by flag, environment or
+// credential helper. Missing ones are left empty.
+func defaultTokens() *token.Tokens {
+	tokens := &token.Tokens{}
+	if t, err := global.Tokens("", token.Github); err == nil {
+		tokens.GithubToken = t.GithubToken
+	}
+	if t, err := global.Tokens("", token.Jira); err == nil {
+		tokens.JiraToken = t.JiraToken
+	}
+	return tokens
+}
+
+// terminal returns the file descriptor of in if it is a terminal.
+var terminal = func(in io.Reader) (int, bool) {
+	f, ok := in.(*os.File)
+	if !ok || !term.IsTerminal(int(f.Fd())) {
+		return 0, false
+	}
+	return int(f.Fd()), true
+}
+
+// overrideable func for mocking term.ReadPassword
+var readPassword = term.ReadPassword
+
+// ask prompts for every setting, offering the current values as defaults.
+// The tokens are not echoed when asked for on a terminal.
+func ask(in io.Reader, out io.Writer, p *config.Profile, tokens *token.Tokens) error {
+	r := bufio.NewReader(in)
+	fd, isTerminal := terminal(in)
+	prompt := func(label string, value *string, secret bool) error {
+		def := *value
+		if secret && def != "" {
+			def = "keep current"
+		}
+		if def != "" {
+			fmt.Fprintf(out, "%s [%s]: ", label, def)
+		} else {
+			fmt.Fprintf(out, "%s: ", label)
+		}
+		if secret && isTerminal {
+			line, err := readPassword(fd)
+			// the newline typed was not echoed either
+			fmt.Fprintln(out)
+			if err != nil {
+				return err
+			}
+			if s := strings.TrimSpace(string(line)); s != "" {
+				*value = s
+			}
+			return nil
+		}
+		line, err := r.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			if err == io.EOF {
+				return errors.New("unexpected end of input")
+			}
+			return err
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			*value = line
+		}
+		return nil
+	}
+
+	if err := prompt("Github project (ORG/REPO)", &p.GithubProject, false); err != nil {
+		return err
+	}
+	if err := prompt("Github Enterprise Server URL (empty for github.com)", &p.GithubURL, false); err != nil {
+		return err
+	}
+	if err := prompt("Github token", &tokens.GithubToken, true); err != nil {
+		return err
+	}
+	if err := prompt("Jira URL", &p.JiraURL, false); err != nil {
+		return err
+	}
+	if err := prompt("Jira project key", &p.JiraProject, false); err != nil {
+		return err
+	}
+	if err := prompt("Jira auth ("+strings.Join(jira.AuthModes, ", ")+")", &p.JiraAuth, false); err != nil {
+		return err
+	}
+	if p.JiraAuth == jira.AuthBasic {
+		if err := prompt("Jira user (email)", &p.JiraUser, false); err != nil {
+			return err
+		}
+	}
+	return prompt("Jira token", &tokens.JiraToken, true)
+}
+
+// validate checks that the tokens are accepted by Github and Jira.
+func validate(out io.Writer, p *config.Profile, tokens *token.Tokens) error {
+	user, err := gh.CurrentUser(
+		gh.WithToken(tokens.GithubToken),
+		gh.WithGithubURL(p.GithubURL),
+		gh.WithTransport(global.GithubTransport()),
+	)
+	if err != nil {
+		return fmt.Errorf("github token does not work: %w", err)
+	}
+	fmt.Fprintf(out, "Github token works, authenticated as %s\n", user.GetLogin())
+
+	me, err := jira.Myself(
+		jira.WithToken(tokens.JiraToken),
+		jira.WithJiraURL(p.JiraURL),
+		jira.WithAuth(p.JiraAuth, p.JiraUser),
+		jira.WithTransport(global.JiraTransport()),
+	)
+	if err != nil {
+		return fmt.Errorf("jira token does not work: %w", err)
+	}
+	fmt.Fprintf(out, "Jira token works, authenticated as %s\n", me.DisplayName)
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genconfig
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/jmrodri/gh2jira/internal/config"
+	"github.com/jmrodri/gh2jira/internal/token"
+)
+
+var _ = Describe("ask", func() {
+	var (
+		savedTerminal     func(io.Reader) (int, bool)
+		savedReadPassword func(int) ([]byte, error)
+	)
+	BeforeEach(func() {
+		savedTerminal, savedReadPassword = terminal, readPassword
+	})
+	AfterEach(func() {
+		terminal, readPassword = savedTerminal, savedReadPassword
+	})
+
+	// answers to all the prompts but the tokens, with bearer auth
+	settings := []string{"foo/bar", "", "https://jira.example.com", "OSDK", "bearer"}
+
+	It("should not echo the tokens typed on a terminal", func() {
+		terminal = func(io.Reader) (int, bool) { return 0, true }
+		secrets := []string{"gh-s3cret", "jira-s3cret"}
+		readPassword = func(int) ([]byte, error) {
+			s := secrets[0]
+			secrets = secrets[1:]
+			return []byte(s), nil
+		}
+
+		in := strings.NewReader(strings.Join(settings, "\n") + "\n")
+		out := &bytes.Buffer{}
+		p := &config.Profile{}
+		tokens := &token.Tokens{}
+		Expect(ask(in, out, p, tokens)).To(Succeed())
+
+		Expect(tokens.GithubToken).To(Equal("gh-s3cret"))
+		Expect(tokens.JiraToken).To(Equal("jira-s3cret"))
+		Expect(p.JiraProject).To(Equal("OSDK"))
+		Expect(out.String()).NotTo(ContainSubstring("s3cret"))
+		Expect(out.String()).To(ContainSubstring("Github token: \n"))
+		Expect(out.String()).To(HaveSuffix("Jira token: \n"))
+	})
+	It("should read the tokens from piped input", func() {
+		readPassword = func(int) ([]byte, error) {
+			Fail("piped input is not a terminal")
+			return nil, nil
+		}
+		answers := []string{settings[0], settings[1], "gh-s3cret", settings[2], settings[3], settings[4], "jira-s3cret"}
+		in := strings.NewReader(strings.Join(answers, "\n") + "\n")
+		out := &bytes.Buffer{}
+		tokens := &token.Tokens{}
+		Expect(ask(in, out, &config.Profile{}, tokens)).To(Succeed())
+
+		Expect(tokens.GithubToken).To(Equal("gh-s3cret"))
+		Expect(tokens.JiraToken).To(Equal("jira-s3cret"))
+		Expect(out.String()).NotTo(ContainSubstring("s3cret"))
+	})
+})
@@ -0,0 +1,194 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package global holds the flags shared by all gh2jira commands.
+package global
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/jmrodri/gh2jira/internal/config"
+	"github.com/jmrodri/gh2jira/internal/gh"
+	"github.com/jmrodri/gh2jira/internal/jira"
+	"github.com/jmrodri/gh2jira/internal/redact"
+	"github.com/jmrodri/gh2jira/internal/token"
+	"github.com/jmrodri/gh2jira/internal/transport"
+)
+
+var (
+	caBundle           string
+	clientCert         string
+	clientKey          string
+	insecureSkipVerify bool
+	githubProxy        string
+	jiraProxy          string
+	colorMode          string
+	githubToken        string
+	jiraToken          string
+	credentialHelper   string
+	configFile         string
+	profileName        string
+	jiraURL            string
+	jiraAuth           string
+	jiraUser           string
+	strictPerms        bool
+	fixPerms           bool
+)
+
+// AddFlags adds the global flags to the given flag set, usually the
+// persistent flags of the root command.
+func AddFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&caBundle, "ca-bundle", "",
+		"PEM file of CA certificates to trust in addition to the system ones")
+	fs.StringVar(&clientCert, "client-cert", "",
+		"PEM client certificate for mutual TLS")
+	fs.StringVar(&clientKey, "client-key", "",
+		"PEM private key of the client certificate")
+	fs.BoolVar(&insecureSkipVerify, "insecure-skip-verify", false,
+		"do not verify server certificates. DANGEROUS, use --ca-bundle instead")
+	fs.StringVar(&githubProxy, "github-proxy", "",
+		"proxy URL for github requests (default from HTTPS_PROXY)")
+	fs.StringVar(&jiraProxy, "jira-proxy", "",
+		"proxy URL for jira requests (default from HTTPS_PROXY)")
+	fs.StringVar(&colorMode, "color", gh.ColorAuto,
+		"colorize the output: "+strings.Join(gh.ColorModes, ", ")+
+			", auto colors terminals unless NO_COLOR is set")
+	fs.StringVar(&githubToken, "github-token", "",
+		"github token, overrides $GITHUB_TOKEN and the token file")
+	fs.StringVar(&jiraToken, "jira-token", "",
+		"jira token, overrides $JIRA_TOKEN and the token file")
+	fs.StringVar(&credentialHelper, "credential-helper", os.Getenv("GH2JIRA_CREDENTIAL_HELPER"),
+		"command run as '<helper> get github|jira' printing the token, used when no other source has it")
+	fs.StringVar(&configFile, "config", "",
+		"configuration file (default $XDG_CONFIG_HOME/gh2jira/config.yaml)")
+	fs.StringVar(&profileName, "profile", config.DefaultProfile,
+		"configuration profile whose settings are the defaults of the flags")
+	fs.StringVar(&jiraURL, "jira-url", config.DefaultJiraURL, "Jira server URL")
+	fs.StringVar(&jiraAuth, "jira-auth", jira.AuthBearer,
+		"how to authenticate to Jira: bearer for a Server or Data Center personal access token, "+
+			"basic for a Jira Cloud user and API token")
+	fs.StringVar(&jiraUser, "jira-user", "",
+		"Jira user, usually an email address, required for basic auth")
+	fs.BoolVar(&strictPerms, "strict", false,
+		"refuse token files group or others can access instead of warning about them")
+	fs.BoolVar(&fixPerms, "fix-perms", false,
+		"make token files group or others can access private to you")
+	BindProfile(fs, map[string]string{
+		"jira-url":  "jiraURL",
+		"jira-auth": "jiraAuth",
+		"jira-user": "jiraUser",
+	})
+}
+
+// Tokens resolves the required tokens, github and/or jira, from the token
+// flags, the environment, the token sources of the profile, the given token
+// file and the credential helper, in that order. The default token file is
+// looked for in the current directory, then in the configuration directory.
+// The tokens are masked in everything printed from then on.
+func Tokens(tokenFile string, required ...string) (*token.Tokens, error) {
+	// mask the secrets known so far in case resolving fails halfway
+	redact.Add(githubToken, jiraToken, os.Getenv(token.PassphraseEnv))
+
+	files := []string{tokenFile}
+	if tokenFile == config.DefaultTokenFile {
+		path, err := config.DefaultTokenPath()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, path)
+	}
+	opts := []token.Option{
+		token.WithFlags(githubToken, jiraToken),
+		token.WithFile(files...),
+		token.WithStrictPermissions(strictPerms),
+		token.WithFixPermissions(fixPerms),
+		token.WithWarnings(warnings),
+		token.WithHelper(credentialHelper),
+		token.WithRequired(required...),
+	}
+	sources, err := loadedProfile.TokenSources()
+	if err != nil {
+		return nil, err
+	}
+	for name, s := range sources {
+		opts = append(opts, token.WithSource(name, s))
+	}
+	tokens, err := token.Resolve(opts...)
+	if err != nil {
+		return nil, redact.Error(err)
+	}
+	redact.Add(tokens.GithubToken, tokens.JiraToken)
+	return tokens, nil
+}
+
+// warnOnce drops the warnings already written, commands resolving the tokens
+// one at a time would repeat them.
+type warnOnce struct {
+	w    io.Writer
+	seen map[string]bool
+}
+
+func (o *warnOnce) Write(p []byte) (int, error) {
+	if o.seen[string(p)] {
+		return len(p), nil
+	}
+	o.seen[string(p)] = true
+	return o.w.Write(p)
+}
+
+var warnings = &warnOnce{w: redact.Stderr, seen: map[string]bool{}}
+
+// ColorMode returns the color mode of the output.
+func ColorMode() string {
+	return colorMode
+}
+
+// GithubTransport returns the transport settings for talking to Github.
+func GithubTransport() *transport.Config {
+	return &transport.Config{
+		Name:               "github",
+		CABundle:           caBundle,
+		ClientCert:         clientCert,
+		ClientKey:          clientKey,
+		Proxy:              githubProxy,
+		InsecureSkipVerify: insecureSkipVerify,
+	}
+}
+
+// JiraServer returns the option selecting the Jira server and how to
+// authenticate to it.
+func JiraServer() jira.Option {
+	return func(c *jira.ClonerConfig) error {
+		if err := jira.WithJiraURL(jiraURL)(c); err != nil {
+			return err
+		}
+		return jira.WithAuth(jiraAuth, jiraUser)(c)
+	}
+}
+
+// JiraTransport returns the transport settings for talking to Jira.
+func JiraTransport() *transport.Config {
+	return &transport.Config{
+		Name:               "jira",
+		CABundle:           caBundle,
+		ClientCert:         clientCert,
+		ClientKey:          clientKey,
+		Proxy:              jiraProxy,
+		InsecureSkipVerify: insecureSkipVerify,
+	}
+}
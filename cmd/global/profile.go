@@ -0,0 +1,160 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package global
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/jmrodri/gh2jira/internal/config"
+)
+
+// profileAnnotation holds the configuration keys a flag takes its default
+// from, the first one set wins.
+const profileAnnotation = "gh2jira_profile"
+
+var (
+	loadedConfig  string
+	loadedProfile = &config.Profile{}
+)
+
+// BindProfile makes the profile settings the defaults of the flags. The
+// bindings map flag names to configuration keys, e.g. "project" to
+// "jiraProject".
+func BindProfile(fs *pflag.FlagSet, bindings map[string]string) {
+	for flag, key := range bindings {
+		keys := []string{key}
+		// list takes the repos of the profile, its repo by default
+		if key == "githubProjects" {
+			keys = append(keys, "githubProject")
+		}
+		if err := fs.SetAnnotation(flag, profileAnnotation, keys); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// LoadProfile reads the selected profile of the configuration file and sets
+// the flags bound to it that were not given on the command line. A missing
+// configuration file or profile is only an error if it was asked for, unless
+// allowNew is set for commands creating it.
+func LoadProfile(flags *pflag.FlagSet, allowNew bool) error {
+	path := configFile
+	if path == "" {
+		var err error
+		if path, err = config.DefaultPath(); err != nil {
+			return err
+		}
+	} else if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) && !allowNew {
+		return fmt.Errorf("configuration file %s not found", path)
+	}
+
+	c, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	p, err := c.Profile(profileName)
+	if err != nil {
+		if !allowNew && flags.Changed("profile") {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		p = &config.Profile{}
+	}
+	loadedConfig = path
+	loadedProfile = p
+
+	values, err := p.Values()
+	if err != nil {
+		return err
+	}
+	return applyProfile(flags, values)
+}
+
+func applyProfile(fs *pflag.FlagSet, values map[string]interface{}) error {
+	var err error
+	fs.VisitAll(func(f *pflag.Flag) {
+		if err != nil || f.Changed {
+			return
+		}
+		for _, key := range f.Annotations[profileAnnotation] {
+			if v, ok := values[key]; ok {
+				if err = setFlag(f, v); err != nil {
+					err = fmt.Errorf("profile %s: %s: %w", profileName, key, err)
+				}
+				return
+			}
+		}
+	})
+	return err
+}
+
+// setFlag sets the flag to a setting of the configuration file. The flag is
+// not marked as changed, it still holds a default.
+func setFlag(f *pflag.Flag, v interface{}) error {
+	switch v := v.(type) {
+	case []interface{}:
+		var values []string
+		for _, e := range v {
+			values = append(values, fmt.Sprint(e))
+		}
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			return sv.Replace(values)
+		}
+		return f.Value.Set(strings.Join(values, ","))
+	case map[string]interface{}:
+		var pairs []string
+		for k, e := range v {
+			pairs = append(pairs, fmt.Sprintf("%s=%v", k, e))
+		}
+		sort.Strings(pairs)
+		return f.Value.Set(strings.Join(pairs, ","))
+	default:
+		s := fmt.Sprint(v)
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			return sv.Replace([]string{s})
+		}
+		return f.Value.Set(s)
+	}
+}
+
+// ConfigFile returns the configuration file that was loaded.
+func ConfigFile() string {
+	return loadedConfig
+}
+
+// ProfileName returns the selected profile.
+func ProfileName() string {
+	return profileName
+}
+
+// Profile returns the settings in effect: the built-in defaults, replaced by
+// the selected profile, replaced by the global flags.
+func Profile() (*config.Profile, error) {
+	p, err := config.Defaults().Merge(loadedProfile)
+	if err != nil {
+		return nil, err
+	}
+	return p.Merge(&config.Profile{
+		JiraURL:  jiraURL,
+		JiraAuth: jiraAuth,
+		JiraUser: jiraUser,
+	})
+}
@@ -15,18 +15,43 @@
 package list
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v47/github"
 	"github.com/spf13/cobra"
 
+	"github.com/jmrodri/gh2jira/cmd/global"
+	"github.com/jmrodri/gh2jira/internal/config"
 	"github.com/jmrodri/gh2jira/internal/gh"
 	"github.com/jmrodri/gh2jira/internal/token"
 )
 
 var (
+	githubURL string
 	tokenFile string
 	milestone string
 	assignee  string
-	project   string
+	projects  []string
+	org       string
+	repoGlob  string
 	label     []string
+	state     string
+	since     string
+	sort      string
+	direction string
+	query     string
+
+	output gh.OutputOptions
+	long   bool
+
+	anyLabel     bool
+	excludeLabel []string
+	noAssignee   bool
+	noMilestone  bool
 )
 
 func NewCmd() *cobra.Command {
@@ -35,41 +60,157 @@ func NewCmd() *cobra.Command {
 		Short: "List Github issues",
 		Long:  "List Github issues filtered by milestone, assignee, or label",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			tokens, err := token.ReadTokensYaml(tokenFile)
+			if cmd.Flags().Changed("org") && !cmd.Flags().Changed("project") {
+				// the default project is not part of the org listing
+				projects = nil
+			}
+			if repoGlob != "" && org == "" {
+				return errors.New("--repo requires --org")
+			}
+			if len(projects) == 0 && org == "" {
+				return errors.New("no Github project to list, set --project or --org")
+			}
+			for _, p := range projects {
+				if p == "" {
+					return errors.New("--project must not be empty")
+				}
+			}
+			if cmd.Flags().Changed("template") && !cmd.Flags().Changed("output") {
+				output.Format = gh.FormatTemplate
+			}
+			if err := output.Validate(); err != nil {
+				return err
+			}
+			printer, err := gh.NewPrinter(os.Stdout, global.ColorMode())
+			if err != nil {
+				return err
+			}
+			sinceTime, err := parseSince(since)
+			if err != nil {
+				return err
+			}
+			tokens, err := global.Tokens(tokenFile, token.Github)
 			if err != nil {
 				return err
 			}
-			issues, err := gh.ListIssues(gh.WithToken(tokens.GithubToken),
+			opts := []gh.Option{gh.WithToken(tokens.GithubToken),
+				gh.WithTransport(global.GithubTransport()),
+				gh.WithGithubURL(githubURL),
 				gh.WithMilestone(milestone),
 				gh.WithAssignee(assignee),
-				gh.WithProject(project),
 				gh.WithLabel(label),
-			)
+				gh.WithAnyLabel(anyLabel),
+				gh.WithExcludeLabel(excludeLabel),
+				gh.WithNoAssignee(noAssignee),
+				gh.WithNoMilestone(noMilestone),
+				gh.WithState(state),
+				gh.WithSince(sinceTime),
+				gh.WithSort(sort),
+				gh.WithDirection(direction),
+			}
+			if org != "" {
+				orgProjects, err := gh.ListOrgProjects(org, repoGlob, opts...)
+				if err != nil {
+					return err
+				}
+				if len(orgProjects) == 0 {
+					return fmt.Errorf("no repos in %s match %q", org, repoGlob)
+				}
+				projects = append(projects, orgProjects...)
+			}
+			multi := len(projects) > 1
+
+			var issues []*github.Issue
+			switch {
+			case query != "":
+				// search all the projects at once
+				issues, err = gh.SearchIssues(query, append(opts, gh.WithProjects(projects))...)
+			case multi:
+				issues, err = gh.ListProjectsIssues(projects, opts...)
+			default:
+				issues, err = gh.ListIssues(append(opts, gh.WithProject(projects[0]))...)
+			}
 			if err != nil {
 				return err
 			}
 
-			// print the issues
+			var onlyIssues []*github.Issue
 			for _, issue := range issues {
 				if issue.IsPullRequest() {
 					// We have a PR, skipping
 					continue
 				}
-				gh.PrintGithubIssue(issue, true, true)
+				onlyIssues = append(onlyIssues, issue)
 			}
-			return nil
+			if output.Format != gh.FormatOneline {
+				return gh.WriteIssues(os.Stdout, onlyIssues, &output)
+			}
+
+			printer.ShowRepo = multi
+			return printer.PrintIssues(onlyIssues, long)
 		},
 	}
 
-	cmd.Flags().StringVar(&tokenFile, "token-file", "tokens.yaml",
+	cmd.Flags().StringVar(&tokenFile, "token-file", config.DefaultTokenFile,
 		"file containing github and jira tokens")
+	cmd.Flags().StringVar(&githubURL, "github-url", "",
+		"URL of a Github Enterprise Server, e.g. https://github.example.com")
 	cmd.Flags().StringVar(&milestone, "milestone", "",
-		"the milestone ID from the url, not the display name")
+		"milestone title, e.g. v1.27.0, number, none or *")
 	cmd.Flags().StringVar(&assignee, "assignee", "", "username of the issue is assigned")
-	cmd.Flags().StringVar(&project, "project", "operator-framework/operator-sdk",
-		"Github project to list e.g. ORG/REPO")
+	cmd.Flags().StringSliceVar(&projects, "project", []string{config.DefaultGithubProject},
+		"Github project to list e.g. ORG/REPO, may be repeated")
+	cmd.Flags().StringVar(&org, "org", "", "list the issues of all the repos of the Github org")
+	cmd.Flags().StringVar(&repoGlob, "repo", "",
+		"only the --org repos matching the glob, e.g. operator-*")
 	cmd.Flags().StringSliceVar(&label, "label", nil,
 		"label i.e. --label \"documentation,bug\" or --label doc --label bug")
+	cmd.Flags().BoolVar(&anyLabel, "any-label", false,
+		"list issues with any of the --label labels instead of all of them")
+	cmd.Flags().StringSliceVar(&excludeLabel, "exclude-label", nil,
+		"skip issues with any of these labels i.e. --exclude-label lifecycle/stale")
+	cmd.Flags().BoolVar(&noAssignee, "no-assignee", false, "only issues nobody is assigned to")
+	cmd.Flags().BoolVar(&noMilestone, "no-milestone", false, "only issues without a milestone")
+	cmd.Flags().StringVar(&query, "query", "",
+		"github issue search query, at most 1000 results, i.e. --query \"author:johndoe comments:>10\"")
+	cmd.Flags().StringVarP(&output.Format, "output", "o", gh.FormatOneline,
+		"output format: "+strings.Join(gh.Formats, ", "))
+	cmd.Flags().BoolVar(&long, "long", false, "print the details of every issue over several lines")
+	cmd.Flags().StringSliceVar(&output.Columns, "columns", nil,
+		"columns of the table and csv output, any of "+strings.Join(gh.Columns(), ", ")+
+			" (default "+strings.Join(gh.DefaultColumns, ",")+")")
+	cmd.Flags().StringVar(&output.Schema, "schema", gh.SchemaSummary,
+		"json and yaml output: summary for the main fields or full for the complete github issues")
+	cmd.Flags().StringVar(&output.Template, "template", "",
+		"go template executed for every issue, implies --output template, i.e. --template '{{.Number}} {{.Title}}'")
+	cmd.Flags().StringVar(&state, "state", "open", "issue state: open, closed or all")
+	cmd.Flags().StringVar(&since, "since", "",
+		"only issues updated at or after the date, i.e. 2022-09-01 or 2022-09-01T15:04:05Z")
+	cmd.Flags().StringVar(&sort, "sort", "", "sort by created, updated or comments (default created)")
+	cmd.Flags().StringVar(&direction, "direction", "", "sort direction: asc or desc (default desc)")
+	global.BindProfile(cmd.Flags(), map[string]string{
+		"token-file": "tokenFile",
+		"github-url": "githubURL",
+		"project":    "githubProjects",
+		"output":     "output",
+		"columns":    "columns",
+		"template":   "template",
+	})
 
 	return cmd
 }
+
+// parseSince parses a date or an RFC 3339 timestamp.
+func parseSince(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since %q, expected a date like 2022-09-01 or a timestamp like 2022-09-01T15:04:05Z", s)
+	}
+	return t, nil
+}
@@ -0,0 +1,120 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mapping
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jmrodri/gh2jira/cmd/global"
+	"github.com/jmrodri/gh2jira/internal/mapping"
+	"github.com/jmrodri/gh2jira/internal/redact"
+)
+
+var (
+	mappingFile string
+	outputFile  string
+)
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mapping",
+		Short: "Manage the Github to Jira issue mapping store",
+		Long: `Manage the store that records which Github issues have been cloned
+to which Jira issues`,
+	}
+
+	cmd.PersistentFlags().StringVar(&mappingFile, "mapping-file", "",
+		"mapping store file (default $XDG_STATE_HOME/gh2jira/mapping.json)")
+	global.BindProfile(cmd.PersistentFlags(), map[string]string{"mapping-file": "mappingFile"})
+
+	cmd.AddCommand(newExportCmd(), newImportCmd())
+
+	return cmd
+}
+
+func newExportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the mapping store as JSON",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := mapping.Open(mappingFile)
+			if err != nil {
+				return err
+			}
+			records, err := store.List()
+			if err != nil {
+				return err
+			}
+			if records == nil {
+				records = []mapping.Record{}
+			}
+
+			data, err := json.MarshalIndent(records, "", "  ")
+			if err != nil {
+				return err
+			}
+			data = append(data, '\n')
+
+			if outputFile == "" {
+				_, err = os.Stdout.Write(data)
+				return err
+			}
+			return os.WriteFile(outputFile, data, 0o600)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "",
+		"file to export to, defaults to stdout")
+
+	return cmd
+}
+
+func newImportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import <FILE>",
+		Short: "Import mappings from a JSON file created by export",
+		Long: `Import mappings from a JSON file created by export. Existing mappings
+of the same Github issues are replaced.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+			var records []mapping.Record
+			if err := json.Unmarshal(data, &records); err != nil {
+				return fmt.Errorf("unable to parse %s: %w", args[0], err)
+			}
+
+			store, err := mapping.Open(mappingFile)
+			if err != nil {
+				return err
+			}
+			n, err := store.Import(records)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(redact.Stdout, "Imported %d mappings into %s\n", n, store.Path())
+			return nil
+		},
+	}
+
+	return cmd
+}
@@ -17,8 +17,20 @@ package root
 import (
 	"github.com/spf13/cobra"
 
+	"github.com/jmrodri/gh2jira/cmd/auth"
 	"github.com/jmrodri/gh2jira/cmd/clone"
+	"github.com/jmrodri/gh2jira/cmd/config"
+	"github.com/jmrodri/gh2jira/cmd/dev"
+	"github.com/jmrodri/gh2jira/cmd/genconfig"
+	"github.com/jmrodri/gh2jira/cmd/global"
 	"github.com/jmrodri/gh2jira/cmd/list"
+	"github.com/jmrodri/gh2jira/cmd/mapping"
+	"github.com/jmrodri/gh2jira/cmd/serve"
+	"github.com/jmrodri/gh2jira/cmd/show"
+	"github.com/jmrodri/gh2jira/cmd/status"
+	"github.com/jmrodri/gh2jira/cmd/token"
+
+	"github.com/jmrodri/gh2jira/internal/redact"
 )
 
 func NewCmd() *cobra.Command {
@@ -26,9 +38,19 @@ func NewCmd() *cobra.Command {
 		Use:   "gh2jira",
 		Short: "github to jira issue cloner",
 		Long:  "",
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return global.LoadProfile(cmd.Flags(), false)
+		},
 	}
-	// add the child commands: list and clone
-	cmd.AddCommand(list.NewCmd(), clone.NewCmd())
+	global.AddFlags(cmd.PersistentFlags())
+	// errors and usage may quote a token given on the command line
+	cmd.SetOut(redact.Stdout)
+	cmd.SetErr(redact.Stderr)
+
+	// add the child commands
+	cmd.AddCommand(list.NewCmd(), clone.NewCmd(), mapping.NewCmd(), status.NewCmd(),
+		serve.NewCmd(), show.NewCmd(), genconfig.NewCmd(),
+		config.NewCmd(), token.NewCmd(), auth.NewCmd(), dev.NewCmd())
 
 	return cmd
 }
@@ -0,0 +1,137 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package root
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jmrodri/gh2jira/internal/jira/mock"
+	"github.com/jmrodri/gh2jira/internal/redact"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// execute runs gh2jira with the arguments and returns what it printed on
+// stdout and stderr.
+func execute(args ...string) (string, error) {
+	r, w, _ := os.Pipe()
+	stdout, stderr := os.Stdout, os.Stderr
+	defer func() {
+		os.Stdout, os.Stderr = stdout, stderr
+	}()
+	os.Stdout, os.Stderr = w, w
+
+	done := make(chan error)
+	go func() {
+		cmd := NewCmd()
+		cmd.SetArgs(args)
+		err := cmd.Execute()
+		w.Close()
+		done <- err
+	}()
+	out, _ := io.ReadAll(r)
+	return string(out), <-done
+}
+
+var _ = Describe("Root", func() {
+	var (
+		dir string
+		env map[string]*string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "gh2jira-root-")
+		Expect(err).NotTo(HaveOccurred())
+		env = map[string]*string{}
+		for _, name := range []string{"XDG_CONFIG_HOME", "GITHUB_TOKEN", "JIRA_TOKEN"} {
+			if old, ok := os.LookupEnv(name); ok {
+				env[name] = &old
+			} else {
+				env[name] = nil
+			}
+			os.Unsetenv(name)
+		}
+		os.Setenv("XDG_CONFIG_HOME", dir)
+	})
+	AfterEach(func() {
+		for name, old := range env {
+			if old != nil {
+				os.Setenv(name, *old)
+			} else {
+				os.Unsetenv(name)
+			}
+		}
+		os.RemoveAll(dir)
+		redact.Reset()
+	})
+
+	// the unreachable server URL ends up in the error, token included
+	It("should not print a token given as a flag in errors or usage", func() {
+		out, err := execute("list", "--github-token", "ghp_flagtoken123",
+			"--github-url", "http://127.0.0.1:1/ghp_flagtoken123")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("Error:"))
+		Expect(out).To(ContainSubstring("Usage:"))
+		Expect(out).To(ContainSubstring("[REDACTED]"))
+		Expect(out).NotTo(ContainSubstring("ghp_flagtoken123"))
+	})
+
+	It("should not print a token from the environment in errors or usage", func() {
+		os.Setenv("GITHUB_TOKEN", "ghp_envtoken123")
+		out, err := execute("list", "--github-url", "http://127.0.0.1:1/ghp_envtoken123")
+		Expect(err).To(HaveOccurred())
+		Expect(out).To(ContainSubstring("Error:"))
+		Expect(out).NotTo(ContainSubstring("ghp_envtoken123"))
+	})
+
+	It("should only clone the issues of a query that pass the filters", func() {
+		github := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Path).To(Equal("/api/v3/search/issues"))
+			// like github, leave out the excluded labels
+			fresh := `{"number": 1, "title": "Fresh bug", "state": "open",
+				"repository_url": "https://api.github.com/repos/foo/bar",
+				"labels": [{"name": "kind/bug"}]}`
+			stale := `{"number": 2, "title": "Stale bug", "state": "open",
+				"repository_url": "https://api.github.com/repos/foo/bar",
+				"labels": [{"name": "kind/bug"}, {"name": "lifecycle/stale"}]}`
+			if strings.Contains(r.URL.Query().Get("q"), `-label:"lifecycle/stale"`) {
+				w.Write([]byte(`{"total_count": 1, "items": [` + fresh + `]}`))
+				return
+			}
+			w.Write([]byte(`{"total_count": 2, "items": [` + fresh + `, ` + stale + `]}`))
+		}))
+		defer github.Close()
+		fake := mock.NewFake(mock.WithFakeProject("OSDK", "Operator SDK"), mock.WithFakeRequestLog(io.Discard))
+		jira := httptest.NewServer(fake)
+		defer jira.Close()
+
+		out, err := execute("clone", "--query", "label:kind/bug", "--exclude-label", "lifecycle/stale",
+			"--github-url", github.URL, "--jira-url", jira.URL, "--project", "OSDK",
+			"--github-token", "gh", "--jira-token", "jira",
+			"--mapping-file", filepath.Join(dir, "mapping.json"))
+		Expect(err).NotTo(HaveOccurred(), out)
+		Expect(out).To(ContainSubstring("Query matched 1 issues"))
+		issues := fake.Issues()
+		Expect(issues).To(HaveLen(1))
+		Expect(issues[0].Fields.Summary).To(ContainSubstring("Fresh bug"))
+	})
+})
@@ -0,0 +1,192 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/go-github/v47/github"
+	"github.com/spf13/cobra"
+
+	"github.com/jmrodri/gh2jira/cmd/global"
+	"github.com/jmrodri/gh2jira/internal/config"
+	"github.com/jmrodri/gh2jira/internal/gh"
+	"github.com/jmrodri/gh2jira/internal/httpserver"
+	"github.com/jmrodri/gh2jira/internal/jira"
+	"github.com/jmrodri/gh2jira/internal/mapping"
+	"github.com/jmrodri/gh2jira/internal/redact"
+	"github.com/jmrodri/gh2jira/internal/token"
+	"github.com/jmrodri/gh2jira/internal/webhook"
+)
+
+const secretEnv = "GH2JIRA_WEBHOOK_SECRET"
+
+var (
+	addr        string
+	dryRun      bool
+	project     string
+	tokenFile   string
+	mappingFile string
+	secretFile  string
+	rules       []string
+
+	sensitiveLabels   []string
+	securityLevel     string
+	restrictedProject string
+	allowSensitive    bool
+)
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Clone Github issues to Jira from Github webhook events",
+		Long: `Run an HTTP server receiving Github issues webhook events on /webhook.
+Issues matching one of the rules are cloned to Jira, unless they have already
+been cloned. Events are acknowledged right away and the issues cloned one at a
+time in the background, the outcome is only logged. The server also exposes a
+health check on /healthz.
+
+Rules have the form ACTION[=PATTERN] where PATTERN is a glob:
+  labeled=jira/clone    the issue was labeled jira/clone
+  milestoned=v1.*       the issue was added to a milestone titled v1.*
+  opened=kind/bug       an issue with the kind/bug label was opened
+  opened                any issue was opened
+
+The webhook secret is read from --secret-file or the ` + secretEnv + `
+environment variable.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			parsed, err := webhook.ParseRules(rules)
+			if err != nil {
+				return err
+			}
+			secret, err := readSecret()
+			if err != nil {
+				return err
+			}
+			redact.Add(string(secret))
+			tokens, err := global.Tokens(tokenFile, token.Github, token.Jira)
+			if err != nil {
+				return err
+			}
+			store, err := mapping.Open(mappingFile)
+			if err != nil {
+				return err
+			}
+
+			// the handler clones one issue at a time, so events for the same
+			// issue delivered together, e.g. when it is labeled and
+			// milestoned in one go, do not clone it twice
+			clone := func(issue *github.Issue) error {
+				org, repo := gh.IssueRepo(issue)
+				key, err := store.Resolve(org, repo, issue.GetNumber())
+				if err != nil {
+					return err
+				}
+				if key != "" {
+					fmt.Fprintf(redact.Stdout, "Issue %s/%s#%d already cloned to %s\n",
+						org, repo, issue.GetNumber(), key)
+					return nil
+				}
+
+				ji, err := jira.Clone(issue,
+					jira.WithToken(tokens.JiraToken),
+					global.JiraServer(),
+					jira.WithTransport(global.JiraTransport()),
+					jira.WithProject(project),
+					jira.WithDryRun(dryRun),
+					jira.WithMappingStore(store),
+					jira.WithSensitivePolicy(&jira.SensitivePolicy{
+						Labels:         sensitiveLabels,
+						SecurityLevel:  securityLevel,
+						Project:        restrictedProject,
+						AllowSensitive: allowSensitive,
+					}),
+				)
+				if err != nil || dryRun {
+					return err
+				}
+				fmt.Fprintf(redact.Stdout, "Cloned issue %s/%s#%d to %s\n", org, repo, issue.GetNumber(), ji.Key)
+				return nil
+			}
+
+			handler, err := webhook.NewHandler(secret, parsed, clone)
+			if err != nil {
+				return err
+			}
+			defer handler.Close()
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			fmt.Fprintf(redact.Stdout, "Listening on %s\n", addr)
+			err = httpserver.Serve(ctx, addr, handler, 30*time.Second)
+			fmt.Fprintln(redact.Stdout, "Server stopped")
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&addr, "addr", ":8080", "address to listen on")
+	cmd.Flags().StringVar(&tokenFile, "token-file", config.DefaultTokenFile,
+		"file containing github and jira tokens")
+	cmd.Flags().StringVar(&mappingFile, "mapping-file", "",
+		"mapping store file (default $XDG_STATE_HOME/gh2jira/mapping.json)")
+	cmd.Flags().StringSliceVar(&sensitiveLabels, "sensitive-label", jira.DefaultSensitiveLabels,
+		"github labels marking an issue as sensitive, may be globs")
+	cmd.Flags().StringVar(&securityLevel, "security-level", "",
+		"jira security level to set on sensitive issues")
+	cmd.Flags().StringVar(&restrictedProject, "restricted-project", "",
+		"jira project to clone sensitive issues to")
+	cmd.Flags().BoolVar(&allowSensitive, "allow-sensitive", false,
+		"clone sensitive issues even without a security level or restricted project")
+	cmd.Flags().StringVar(&secretFile, "secret-file", "",
+		"file containing the webhook secret")
+	cmd.Flags().BoolVar(&dryRun, "dryrun", false, "display what we would do without cloning")
+	cmd.Flags().StringVar(&project, "project", config.DefaultJiraProject, "Jira project to clone to")
+	cmd.Flags().StringArrayVar(&rules, "rule", []string{"labeled=jira/clone"},
+		"rule deciding which events clone the issue, may be repeated")
+	global.BindProfile(cmd.Flags(), map[string]string{
+		"token-file":         "tokenFile",
+		"mapping-file":       "mappingFile",
+		"project":            "jiraProject",
+		"sensitive-label":    "sensitiveLabels",
+		"security-level":     "securityLevel",
+		"restricted-project": "restrictedProject",
+		"rule":               "rules",
+	})
+
+	return cmd
+}
+
+func readSecret() ([]byte, error) {
+	if secretFile != "" {
+		data, err := os.ReadFile(secretFile)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(strings.TrimSpace(string(data))), nil
+	}
+	if s := os.Getenv(secretEnv); s != "" {
+		return []byte(s), nil
+	}
+	return nil, errors.New("missing webhook secret, use --secret-file or " + secretEnv)
+}
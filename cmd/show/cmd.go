@@ -0,0 +1,145 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package show
+
+import (
+	"fmt"
+	"os"
+
+	gojira "github.com/andygrunwald/go-jira"
+	"github.com/spf13/cobra"
+
+	"github.com/jmrodri/gh2jira/cmd/global"
+	"github.com/jmrodri/gh2jira/internal/config"
+	"github.com/jmrodri/gh2jira/internal/gh"
+	"github.com/jmrodri/gh2jira/internal/jira"
+	"github.com/jmrodri/gh2jira/internal/mapping"
+	"github.com/jmrodri/gh2jira/internal/redact"
+	"github.com/jmrodri/gh2jira/internal/token"
+)
+
+var (
+	githubURL   string
+	tokenFile   string
+	mappingFile string
+	project     string
+	noComments  bool
+)
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "show <ISSUE>",
+		Short: "Show a Github issue in detail",
+		Long: `Show a Github issue in detail: its fields, body, comments, linked pull
+requests and, if it was cloned, the Jira issue and its status.
+
+The issue is given by number, ORG/REPO#NUMBER or URL.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			printer, err := gh.NewPrinter(os.Stdout, global.ColorMode())
+			if err != nil {
+				return err
+			}
+			tokens, err := global.Tokens(tokenFile, token.Github)
+			if err != nil {
+				return err
+			}
+			opts := []gh.Option{
+				gh.WithToken(tokens.GithubToken),
+				gh.WithTransport(global.GithubTransport()),
+				gh.WithGithubURL(githubURL),
+				gh.WithProject(project),
+			}
+			issue, err := gh.GetIssueRef(args[0], opts...)
+			if err != nil {
+				return err
+			}
+			org, repo := gh.IssueRepo(issue)
+			if org != "" {
+				opts = append(opts, gh.WithProject(org+"/"+repo))
+			} else {
+				lc := gh.ListerConfig{Project: project}
+				org, repo = lc.GetGithubOrg(), lc.GetGithubRepo()
+			}
+
+			detail := &gh.IssueDetail{Issue: issue}
+			if !noComments {
+				detail.Comments, err = gh.ListComments(issue.GetNumber(), opts...)
+				if err != nil {
+					return err
+				}
+			}
+			detail.PullRequests, err = gh.ListLinkedPullRequests(issue.GetNumber(), opts...)
+			if err != nil {
+				return err
+			}
+
+			store, err := mapping.Open(mappingFile)
+			if err != nil {
+				return err
+			}
+			rec, err := store.Get(org, repo, issue.GetNumber())
+			if err != nil {
+				return err
+			}
+			if rec != nil {
+				detail.JiraKey = rec.JiraKey
+				jiraIssues, err := jiraStatus(tokenFile, rec.JiraKey)
+				if err != nil {
+					// the rest of the issue is still worth showing
+					fmt.Fprintf(redact.Stderr, "Warning: unable to get the status of %s: %v\n", rec.JiraKey, err)
+				} else if ji, ok := jiraIssues[rec.JiraKey]; !ok {
+					detail.JiraStatus = "not found"
+				} else if ji.Fields != nil && ji.Fields.Status != nil {
+					detail.JiraStatus = ji.Fields.Status.Name
+				}
+			}
+
+			return printer.PrintDetail(detail)
+		},
+	}
+
+	cmd.Flags().StringVar(&tokenFile, "token-file", config.DefaultTokenFile,
+		"file containing github and jira tokens")
+	cmd.Flags().StringVar(&githubURL, "github-url", "",
+		"URL of a Github Enterprise Server, e.g. https://github.example.com")
+	cmd.Flags().StringVar(&mappingFile, "mapping-file", "",
+		"mapping store file (default $XDG_STATE_HOME/gh2jira/mapping.json)")
+	cmd.Flags().StringVar(&project, "project", config.DefaultGithubProject,
+		"Github project of issues given by number e.g. ORG/REPO")
+	cmd.Flags().BoolVar(&noComments, "no-comments", false, "do not show the comments")
+	global.BindProfile(cmd.Flags(), map[string]string{
+		"token-file":   "tokenFile",
+		"github-url":   "githubURL",
+		"mapping-file": "mappingFile",
+		"project":      "githubProject",
+	})
+
+	return cmd
+}
+
+// jiraStatus looks up the jira issue. Only showing a cloned issue needs the
+// jira token, so it is resolved here.
+func jiraStatus(tokenFile, key string) (map[string]*gojira.Issue, error) {
+	tokens, err := global.Tokens(tokenFile, token.Jira)
+	if err != nil {
+		return nil, err
+	}
+	return jira.GetIssues([]string{key},
+		jira.WithToken(tokens.JiraToken),
+		global.JiraServer(),
+		jira.WithTransport(global.JiraTransport()),
+	)
+}
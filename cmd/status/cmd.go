@@ -0,0 +1,214 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	gojira "github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v47/github"
+	"github.com/spf13/cobra"
+
+	"github.com/jmrodri/gh2jira/cmd/global"
+	"github.com/jmrodri/gh2jira/internal/config"
+	"github.com/jmrodri/gh2jira/internal/gh"
+	"github.com/jmrodri/gh2jira/internal/jira"
+	"github.com/jmrodri/gh2jira/internal/mapping"
+	"github.com/jmrodri/gh2jira/internal/redact"
+	"github.com/jmrodri/gh2jira/internal/token"
+)
+
+var (
+	githubURL   string
+	tokenFile   string
+	mappingFile string
+	milestone   string
+	assignee    string
+	project     string
+	state       string
+	label       []string
+	uncloned    bool
+	drifted     bool
+
+	anyLabel     bool
+	excludeLabel []string
+	noAssignee   bool
+	noMilestone  bool
+)
+
+type row struct {
+	issue      *github.Issue
+	rec        *mapping.Record
+	jiraStatus string
+	drift      []string
+}
+
+func (r *row) key() string {
+	if r.rec == nil {
+		return ""
+	}
+	return r.rec.JiraKey
+}
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the Jira counterpart of Github issues",
+		Long: `Show Github issues along with the Jira issue they were cloned to, its
+status and whether the two have drifted apart since the clone. Closed Github
+issues are included unless --state says otherwise, e.g. to spot those whose
+Jira issue is still open.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tokens, err := global.Tokens(tokenFile, token.Github, token.Jira)
+			if err != nil {
+				return err
+			}
+			store, err := mapping.Open(mappingFile)
+			if err != nil {
+				return err
+			}
+			issues, err := gh.ListIssues(gh.WithToken(tokens.GithubToken),
+				gh.WithTransport(global.GithubTransport()),
+				gh.WithGithubURL(githubURL),
+				gh.WithMilestone(milestone),
+				gh.WithAssignee(assignee),
+				gh.WithProject(project),
+				gh.WithState(state),
+				gh.WithLabel(label),
+				gh.WithAnyLabel(anyLabel),
+				gh.WithExcludeLabel(excludeLabel),
+				gh.WithNoAssignee(noAssignee),
+				gh.WithNoMilestone(noMilestone),
+			)
+			if err != nil {
+				return err
+			}
+
+			var rows []*row
+			var keys []string
+			for _, issue := range issues {
+				if issue.IsPullRequest() {
+					// We have a PR, skipping
+					continue
+				}
+				org, repo := gh.IssueRepo(issue)
+				if org == "" {
+					lc := gh.ListerConfig{Project: project}
+					org, repo = lc.GetGithubOrg(), lc.GetGithubRepo()
+				}
+				rec, err := store.Get(org, repo, issue.GetNumber())
+				if err != nil {
+					return err
+				}
+				rows = append(rows, &row{issue: issue, rec: rec})
+				if rec != nil {
+					keys = append(keys, rec.JiraKey)
+				}
+			}
+
+			var jiraIssues map[string]*gojira.Issue
+			if len(keys) > 0 {
+				jiraIssues, err = jira.GetIssues(keys,
+					jira.WithToken(tokens.JiraToken),
+					global.JiraServer(),
+					jira.WithTransport(global.JiraTransport()),
+				)
+				if err != nil {
+					return err
+				}
+			}
+
+			w := tabwriter.NewWriter(redact.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "ISSUE\tSTATE\tJIRA\tJIRA STATUS\tDRIFT\tTITLE")
+			for _, r := range rows {
+				if r.rec != nil {
+					if ji, ok := jiraIssues[r.rec.JiraKey]; ok {
+						if ji.Fields != nil && ji.Fields.Status != nil {
+							r.jiraStatus = ji.Fields.Status.Name
+						}
+						r.drift = mapping.Drift(r.rec, r.issue, jira.IsDone(ji))
+					} else {
+						r.jiraStatus = "not found"
+						r.drift = []string{"jira issue missing"}
+					}
+				}
+
+				if !show(r) {
+					continue
+				}
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n",
+					r.issue.GetNumber(),
+					r.issue.GetState(),
+					orDash(r.key()),
+					orDash(r.jiraStatus),
+					orDash(strings.Join(r.drift, ", ")),
+					r.issue.GetTitle())
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVar(&tokenFile, "token-file", config.DefaultTokenFile,
+		"file containing github and jira tokens")
+	cmd.Flags().StringVar(&githubURL, "github-url", "",
+		"URL of a Github Enterprise Server, e.g. https://github.example.com")
+	cmd.Flags().StringVar(&mappingFile, "mapping-file", "",
+		"mapping store file (default $XDG_STATE_HOME/gh2jira/mapping.json)")
+	cmd.Flags().StringVar(&milestone, "milestone", "",
+		"milestone title, e.g. v1.27.0, number, none or *")
+	cmd.Flags().StringVar(&assignee, "assignee", "", "username of the issue is assigned")
+	cmd.Flags().StringVar(&project, "project", config.DefaultGithubProject,
+		"Github project to list e.g. ORG/REPO")
+	// all by default, closing the Github issue is the most common drift
+	cmd.Flags().StringVar(&state, "state", "all", "issue state: open, closed or all")
+	cmd.Flags().StringSliceVar(&label, "label", nil,
+		"label i.e. --label \"documentation,bug\" or --label doc --label bug")
+	cmd.Flags().BoolVar(&anyLabel, "any-label", false,
+		"list issues with any of the --label labels instead of all of them")
+	cmd.Flags().StringSliceVar(&excludeLabel, "exclude-label", nil,
+		"skip issues with any of these labels i.e. --exclude-label lifecycle/stale")
+	cmd.Flags().BoolVar(&noAssignee, "no-assignee", false, "only issues nobody is assigned to")
+	cmd.Flags().BoolVar(&noMilestone, "no-milestone", false, "only issues without a milestone")
+	cmd.Flags().BoolVar(&uncloned, "uncloned", false,
+		"only show issues that have not been cloned to jira")
+	cmd.Flags().BoolVar(&drifted, "drifted", false,
+		"only show cloned issues that have drifted from their jira issue")
+	global.BindProfile(cmd.Flags(), map[string]string{
+		"token-file":   "tokenFile",
+		"github-url":   "githubURL",
+		"mapping-file": "mappingFile",
+		"project":      "githubProject",
+	})
+
+	return cmd
+}
+
+// show applies the --uncloned and --drifted filters. When both are given
+// issues matching either are shown.
+func show(r *row) bool {
+	if !uncloned && !drifted {
+		return true
+	}
+	return (uncloned && r.rec == nil) || (drifted && len(r.drift) > 0)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
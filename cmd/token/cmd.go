@@ -0,0 +1,92 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/jmrodri/gh2jira/internal/config"
+	"github.com/jmrodri/gh2jira/internal/token"
+)
+
+var (
+	outputFile string
+	force      bool
+)
+
+func NewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "token",
+		Short: "Manage token files",
+		Long: `Manage the files tokens are read from. See the githubTokenSource and
+jiraTokenSource settings of the configuration profiles.`,
+	}
+
+	cmd.AddCommand(newEncryptCmd())
+
+	return cmd
+}
+
+func newEncryptCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "encrypt",
+		Short: "Write a token read from stdin to an encrypted file",
+		Long: `Read a token from stdin and write it to --output encrypted with the
+passphrase in $` + token.PassphraseEnv + `. Configure the file as the
+encryptedFile token source of a profile.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			passphrase := os.Getenv(token.PassphraseEnv)
+			if passphrase == "" {
+				return fmt.Errorf("%s is not set", token.PassphraseEnv)
+			}
+			line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+			if err != nil && line == "" {
+				return errors.New("no token given on stdin")
+			}
+			t := strings.TrimSpace(line)
+			if t == "" {
+				return errors.New("no token given on stdin")
+			}
+
+			data, err := token.Encrypt(t, passphrase)
+			if err != nil {
+				return err
+			}
+			if err := config.WriteFile(outputFile, data, force); errors.Is(err, fs.ErrExist) {
+				return fmt.Errorf("%w, use --force to overwrite it", err)
+			} else if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Wrote %s\n", outputFile)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "encrypted token file to write")
+	cmd.Flags().BoolVar(&force, "force", false, "overwrite an existing file")
+	if err := cmd.MarkFlagRequired("output"); err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
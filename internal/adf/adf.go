@@ -0,0 +1,193 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package adf renders Github Markdown as Atlassian Document Format, the rich
+// text format of the Jira Cloud REST v3 API.
+//
+// See https://developer.atlassian.com/cloud/jira/platform/apis/document/structure/
+package adf
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/jmrodri/gh2jira/internal/markdown"
+)
+
+// Node is an ADF node. A Document is the root Node.
+type Node struct {
+	Type    string                 `json:"type"`
+	Version int                    `json:"version,omitempty"`
+	Attrs   map[string]interface{} `json:"attrs,omitempty"`
+	Content []*Node                `json:"content,omitempty"`
+	Text    string                 `json:"text,omitempty"`
+	Marks   []Mark                 `json:"marks,omitempty"`
+}
+
+// Mark formats a text node.
+type Mark struct {
+	Type  string                 `json:"type"`
+	Attrs map[string]interface{} `json:"attrs,omitempty"`
+}
+
+// Options controls how Markdown is rendered.
+type Options struct {
+	// MentionURL is the base URL @mentions link to, e.g. https://github.com.
+	// Github users are not Jira users so mentions become links to the
+	// Github profile.
+	MentionURL string
+}
+
+// Document returns an empty ADF document.
+func Document() *Node {
+	return &Node{Type: "doc", Version: 1, Content: []*Node{}}
+}
+
+// Render converts the Github Markdown to an ADF document.
+func Render(md string, opts Options) *Node {
+	doc := Document()
+	doc.Content = append(doc.Content, renderBlocks(markdown.Parse(md), opts)...)
+	return doc
+}
+
+// MarshalJSON always writes the content of a document, which Jira requires
+// even when empty.
+func (n *Node) MarshalJSON() ([]byte, error) {
+	type node Node
+	if n.Type != "doc" || len(n.Content) > 0 {
+		return json.Marshal((*node)(n))
+	}
+	return json.Marshal(struct {
+		*node
+		Content []*Node `json:"content"`
+	}{(*node)(n), []*Node{}})
+}
+
+// Paragraph returns a paragraph of the given inline nodes.
+func Paragraph(content ...*Node) *Node {
+	return &Node{Type: "paragraph", Content: content}
+}
+
+// Text returns a text node with the given marks.
+func Text(s string, marks ...Mark) *Node {
+	return &Node{Type: "text", Text: s, Marks: marks}
+}
+
+// Link returns a link mark to href.
+func Link(href string) Mark {
+	return Mark{Type: "link", Attrs: map[string]interface{}{"href": href}}
+}
+
+func renderBlocks(blocks []markdown.Block, opts Options) []*Node {
+	var nodes []*Node
+	for _, b := range blocks {
+		if n := renderBlock(b, opts); n != nil {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
+
+func renderBlock(b markdown.Block, opts Options) *Node {
+	switch b.Kind {
+	case markdown.Heading:
+		return &Node{
+			Type:    "heading",
+			Attrs:   map[string]interface{}{"level": b.Level},
+			Content: renderInlines(b.Inlines, opts),
+		}
+	case markdown.CodeBlock:
+		n := &Node{Type: "codeBlock"}
+		if b.Language != "" {
+			n.Attrs = map[string]interface{}{"language": b.Language}
+		}
+		if b.Text != "" {
+			// text nodes must not be empty
+			n.Content = []*Node{Text(b.Text)}
+		}
+		return n
+	case markdown.List:
+		n := &Node{Type: "bulletList"}
+		if b.Ordered {
+			n.Type = "orderedList"
+			if b.Start > 1 {
+				n.Attrs = map[string]interface{}{"order": b.Start}
+			}
+		}
+		for _, item := range b.Items {
+			content := renderBlocks(item, opts)
+			// a list item has to start with a paragraph
+			if len(content) == 0 || content[0].Type != "paragraph" {
+				content = append([]*Node{Paragraph()}, content...)
+			}
+			n.Content = append(n.Content, &Node{Type: "listItem", Content: content})
+		}
+		return n
+	case markdown.Quote:
+		content := renderBlocks(b.Children, opts)
+		if len(content) == 0 {
+			return nil
+		}
+		return &Node{Type: "blockquote", Content: content}
+	case markdown.Rule:
+		return &Node{Type: "rule"}
+	default:
+		return Paragraph(renderInlines(b.Inlines, opts)...)
+	}
+}
+
+func renderInlines(inlines []markdown.Inline, opts Options) []*Node {
+	var nodes []*Node
+	for _, in := range inlines {
+		switch in.Kind {
+		case markdown.Break:
+			nodes = append(nodes, &Node{Type: "hardBreak"})
+		case markdown.Mention:
+			base := strings.TrimSuffix(opts.MentionURL, "/")
+			if base == "" {
+				base = "https://github.com"
+			}
+			nodes = append(nodes, Text("@"+in.Text, Link(base+"/"+in.Text)))
+		default:
+			if in.Text == "" {
+				continue
+			}
+			nodes = append(nodes, Text(in.Text, marks(in)...))
+		}
+	}
+	return nodes
+}
+
+// marks returns the ADF marks of a text run. The code mark may only be
+// combined with a link.
+func marks(in markdown.Inline) []Mark {
+	var m []Mark
+	if in.Code {
+		m = append(m, Mark{Type: "code"})
+	} else {
+		if in.Strong {
+			m = append(m, Mark{Type: "strong"})
+		}
+		if in.Emphasis {
+			m = append(m, Mark{Type: "em"})
+		}
+		if in.Strike {
+			m = append(m, Mark{Type: "strike"})
+		}
+	}
+	if in.URL != "" {
+		m = append(m, Link(in.URL))
+	}
+	return m
+}
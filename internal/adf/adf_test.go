@@ -0,0 +1,93 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package adf
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ADF", func() {
+	Describe("Render", func() {
+		It("should return an empty document for an empty body", func() {
+			doc := Render("", Options{})
+			Expect(doc.Type).To(Equal("doc"))
+			Expect(doc.Version).To(Equal(1))
+			Expect(doc.Content).To(BeEmpty())
+			b, err := json.Marshal(doc)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(b)).To(Equal(`{"type":"doc","version":1,"content":[]}`))
+		})
+		It("should render paragraphs with hard breaks", func() {
+			doc := Render("one\ntwo", Options{})
+			Expect(doc.Content).To(Equal([]*Node{
+				Paragraph(Text("one"), &Node{Type: "hardBreak"}, Text("two")),
+			}))
+		})
+		It("should render marks and links", func() {
+			doc := Render("**bold** `code` [docs](https://example.com)", Options{})
+			Expect(doc.Content).To(Equal([]*Node{
+				Paragraph(
+					Text("bold", Mark{Type: "strong"}),
+					Text(" "),
+					Text("code", Mark{Type: "code"}),
+					Text(" "),
+					Text("docs", Link("https://example.com")),
+				),
+			}))
+		})
+		It("should link mentions to the Github profile", func() {
+			doc := Render("@jmrodri", Options{MentionURL: "https://github.example.com/"})
+			Expect(doc.Content).To(Equal([]*Node{
+				Paragraph(Text("@jmrodri", Link("https://github.example.com/jmrodri"))),
+			}))
+		})
+		It("should render code blocks", func() {
+			doc := Render("```yaml\nkey: value\n```\n\n```\n```", Options{})
+			Expect(doc.Content).To(Equal([]*Node{
+				{Type: "codeBlock", Attrs: map[string]interface{}{"language": "yaml"},
+					Content: []*Node{Text("key: value")}},
+				{Type: "codeBlock"},
+			}))
+		})
+		It("should render lists", func() {
+			doc := Render("2. two\n3. three", Options{})
+			Expect(doc.Content).To(HaveLen(1))
+			list := doc.Content[0]
+			Expect(list.Type).To(Equal("orderedList"))
+			Expect(list.Attrs).To(HaveKeyWithValue("order", 2))
+			Expect(list.Content).To(HaveLen(2))
+			Expect(list.Content[0].Type).To(Equal("listItem"))
+			Expect(list.Content[0].Content).To(Equal([]*Node{Paragraph(Text("two"))}))
+		})
+		It("should start list items with a paragraph", func() {
+			doc := Render("- ```\n  code\n  ```", Options{})
+			item := doc.Content[0].Content[0]
+			Expect(item.Content).To(HaveLen(2))
+			Expect(item.Content[0].Type).To(Equal("paragraph"))
+			Expect(item.Content[1].Type).To(Equal("codeBlock"))
+		})
+		It("should render headings, quotes and rules", func() {
+			doc := Render("# Title\n\n> quoted\n\n***", Options{})
+			Expect(doc.Content).To(Equal([]*Node{
+				{Type: "heading", Attrs: map[string]interface{}{"level": 1}, Content: []*Node{Text("Title")}},
+				{Type: "blockquote", Content: []*Node{Paragraph(Text("quoted"))}},
+				{Type: "rule"},
+			}))
+		})
+	})
+})
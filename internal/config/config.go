@@ -0,0 +1,317 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package config reads and writes the gh2jira configuration file.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jmrodri/gh2jira/internal/jiraauth"
+	"github.com/jmrodri/gh2jira/internal/token"
+)
+
+const (
+	// DefaultProfile is the profile used when none is selected.
+	DefaultProfile = "default"
+
+	// The built-in defaults, used when neither a flag nor the profile set
+	// them.
+	DefaultGithubProject = "operator-framework/operator-sdk"
+	DefaultJiraURL       = "https://issues.redhat.com"
+	DefaultJiraProject   = "OSDK"
+	DefaultTokenFile     = "tokens.yaml"
+
+	configFile = "config.yaml"
+	tokenFile  = "tokens.yaml"
+)
+
+// Config is the gh2jira configuration file. It holds named profiles, e.g.
+// one per Github project.
+type Config struct {
+	Profiles map[string]*Profile `yaml:"profiles"`
+}
+
+// Profile holds the settings of a Github project and the Jira project its
+// issues are cloned to. Tokens are kept in the separate token file. Every
+// setting is the default of the matching command line flag.
+type Profile struct {
+	// GithubProject is the ORG/REPO issues are cloned from. GithubProjects
+	// are the repos listed by list, GithubProject by default.
+	GithubProject  string   `yaml:"githubProject,omitempty"`
+	GithubProjects []string `yaml:"githubProjects,omitempty"`
+	GithubURL      string   `yaml:"githubURL,omitempty"`
+
+	JiraURL        string `yaml:"jiraURL,omitempty"`
+	JiraProject    string `yaml:"jiraProject,omitempty"`
+	JiraAuth       string `yaml:"jiraAuth,omitempty"`
+	JiraUser       string `yaml:"jiraUser,omitempty"`
+	JiraAPIVersion int    `yaml:"jiraAPIVersion,omitempty"`
+
+	TokenFile   string `yaml:"tokenFile,omitempty"`
+	MappingFile string `yaml:"mappingFile,omitempty"`
+
+	// GithubTokenSource and JiraTokenSource say where the tokens come from
+	// instead of the token file, e.g. a command or the keyring.
+	GithubTokenSource *token.SourceConfig `yaml:"githubTokenSource,omitempty"`
+	JiraTokenSource   *token.SourceConfig `yaml:"jiraTokenSource,omitempty"`
+
+	// mapping rules
+	UserMap           map[string]string `yaml:"userMap,omitempty"`
+	Watchers          []string          `yaml:"watchers,omitempty"`
+	SensitiveLabels   []string          `yaml:"sensitiveLabels,omitempty"`
+	SecurityLevel     string            `yaml:"securityLevel,omitempty"`
+	RestrictedProject string            `yaml:"restrictedProject,omitempty"`
+	Rules             []string          `yaml:"rules,omitempty"`
+
+	// filters of clone --query
+	AnyLabel      bool     `yaml:"anyLabel,omitempty"`
+	ExcludeLabels []string `yaml:"excludeLabels,omitempty"`
+	NoAssignee    bool     `yaml:"noAssignee,omitempty"`
+	NoMilestone   bool     `yaml:"noMilestone,omitempty"`
+
+	// templates
+	Output   string   `yaml:"output,omitempty"`
+	Columns  []string `yaml:"columns,omitempty"`
+	Template string   `yaml:"template,omitempty"`
+}
+
+// Defaults returns the built-in defaults.
+func Defaults() *Profile {
+	return &Profile{
+		GithubProject: DefaultGithubProject,
+		JiraURL:       DefaultJiraURL,
+		JiraProject:   DefaultJiraProject,
+		JiraAuth:      jiraauth.Bearer,
+		TokenFile:     DefaultTokenFile,
+	}
+}
+
+// Load reads the configuration file. A missing file is an empty
+// configuration. Unknown settings are an error, they are likely typos.
+func Load(path string) (*Config, error) {
+	c := &Config{Profiles: map[string]*Profile{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if c.Profiles == nil {
+		c.Profiles = map[string]*Profile{}
+	}
+	for name, p := range c.Profiles {
+		if p == nil {
+			c.Profiles[name] = &Profile{}
+			continue
+		}
+		if err := p.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: profile %s: %w", path, name, err)
+		}
+	}
+	return c, nil
+}
+
+// Profile returns the named profile.
+func (c *Config) Profile(name string) (*Profile, error) {
+	if p, ok := c.Profiles[name]; ok {
+		return p, nil
+	}
+	var names []string
+	for n := range c.Profiles {
+		names = append(names, n)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("profile %q not found, there are no profiles", name)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("profile %q not found, must be one of %s", name, strings.Join(names, ", "))
+}
+
+// Merge returns a copy of the profile with the settings of over replacing its
+// own.
+func (p *Profile) Merge(over *Profile) (*Profile, error) {
+	values, err := p.Values()
+	if err != nil {
+		return nil, err
+	}
+	overValues, err := over.Values()
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range overValues {
+		values[k] = v
+	}
+	data, err := yaml.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	var merged Profile
+	if err := yaml.Unmarshal(data, &merged); err != nil {
+		return nil, err
+	}
+	return &merged, nil
+}
+
+// Values returns the settings that are set, keyed by their name in the
+// configuration file.
+func (p *Profile) Values() (map[string]interface{}, error) {
+	data, err := yaml.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// Dir returns the gh2jira configuration directory,
+// $XDG_CONFIG_HOME/gh2jira.
+func Dir() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "gh2jira"), nil
+}
+
+// DefaultPath returns the configuration file in the configuration directory.
+func DefaultPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, configFile), nil
+}
+
+// DefaultTokenPath returns the token file in the configuration directory.
+func DefaultTokenPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, tokenFile), nil
+}
+
+// Validate checks the profile settings.
+func (p *Profile) Validate() error {
+	if p.GithubProject != "" {
+		s := strings.Split(p.GithubProject, "/")
+		if len(s) != 2 || s[0] == "" || s[1] == "" {
+			return fmt.Errorf("invalid github project %q, must be ORG/REPO", p.GithubProject)
+		}
+	}
+	if err := validateURL("github", p.GithubURL); err != nil {
+		return err
+	}
+	if err := validateURL("jira", p.JiraURL); err != nil {
+		return err
+	}
+	if _, err := p.TokenSources(); err != nil {
+		return err
+	}
+	return jiraauth.Validate(p.JiraAuth, p.JiraUser)
+}
+
+// TokenSources returns the configured token sources keyed by token.
+func (p *Profile) TokenSources() (map[string]token.Source, error) {
+	sources := map[string]token.Source{}
+	for name, c := range map[string]*token.SourceConfig{
+		token.Github: p.GithubTokenSource,
+		token.Jira:   p.JiraTokenSource,
+	} {
+		if c == nil {
+			continue
+		}
+		s, err := c.Source()
+		if err != nil {
+			return nil, fmt.Errorf("%s token source: %w", name, err)
+		}
+		sources[name] = s
+	}
+	return sources, nil
+}
+
+func validateURL(name, u string) error {
+	if u == "" {
+		return nil
+	}
+	parsed, err := url.Parse(u)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid %s url %q, must be an http or https URL", name, u)
+	}
+	return nil
+}
+
+// Write writes the configuration to path. An existing file is only
+// overwritten if force is set.
+func (c *Config) Write(path string, force bool) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return WriteFile(path, data, force)
+}
+
+// WriteFile writes data to path readable only by the user, creating the
+// directory if needed. An existing file is only overwritten if force is set,
+// otherwise the error wraps fs.ErrExist.
+func WriteFile(path string, data []byte, force bool) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, 0o600)
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("%s: %w", path, fs.ErrExist)
+	} else if err != nil {
+		return err
+	}
+	// an overwritten file keeps its mode
+	if err := f.Chmod(0o600); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
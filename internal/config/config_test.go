@@ -0,0 +1,186 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/jmrodri/gh2jira/internal/token"
+)
+
+var _ = Describe("Config", func() {
+	var dir string
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "config")
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Describe("DefaultPath", func() {
+		It("should use XDG_CONFIG_HOME if it is set", func() {
+			tmp := os.Getenv("XDG_CONFIG_HOME")
+			defer os.Setenv("XDG_CONFIG_HOME", tmp)
+
+			os.Setenv("XDG_CONFIG_HOME", "/tmp/config")
+			path, err := DefaultPath()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(path).To(Equal("/tmp/config/gh2jira/config.yaml"))
+			path, err = DefaultTokenPath()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(path).To(Equal("/tmp/config/gh2jira/tokens.yaml"))
+		})
+	})
+
+	Describe("Load", func() {
+		It("should return an empty configuration if the file is missing", func() {
+			c, err := Load(filepath.Join(dir, "missing.yaml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.Profiles).To(BeEmpty())
+		})
+		It("should read the profiles", func() {
+			path := filepath.Join(dir, "config.yaml")
+			Expect(os.WriteFile(path, []byte(`profiles:
+  default:
+    githubProject: foo/bar
+  cloud:
+    jiraURL: https://example.atlassian.net
+    userMap:
+      janedoe: jane@example.com
+`), 0o600)).To(Succeed())
+
+			c, err := Load(path)
+			Expect(err).NotTo(HaveOccurred())
+			p, err := c.Profile("cloud")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(p.JiraURL).To(Equal("https://example.atlassian.net"))
+			Expect(p.UserMap).To(Equal(map[string]string{"janedoe": "jane@example.com"}))
+		})
+		It("should reject unknown settings", func() {
+			path := filepath.Join(dir, "config.yaml")
+			Expect(os.WriteFile(path, []byte("profiles:\n  default:\n    jiraProjet: FOO\n"), 0o600)).To(Succeed())
+			_, err := Load(path)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("field jiraProjet not found"))
+		})
+		It("should reject invalid profiles", func() {
+			path := filepath.Join(dir, "config.yaml")
+			Expect(os.WriteFile(path, []byte("profiles:\n  bad:\n    githubProject: foo\n"), 0o600)).To(Succeed())
+			_, err := Load(path)
+			Expect(err).To(MatchError(path + `: profile bad: invalid github project "foo", must be ORG/REPO`))
+		})
+	})
+
+	Describe("Profile", func() {
+		It("should name the existing profiles if it is not found", func() {
+			c := &Config{Profiles: map[string]*Profile{"b": {}, "a": {}}}
+			_, err := c.Profile("c")
+			Expect(err).To(MatchError(`profile "c" not found, must be one of a, b`))
+		})
+	})
+
+	Describe("Merge", func() {
+		It("should replace the settings that are set", func() {
+			p, err := Defaults().Merge(&Profile{
+				JiraProject: "FOO",
+				Watchers:    []string{"jdoe"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(p.GithubProject).To(Equal(DefaultGithubProject))
+			Expect(p.JiraURL).To(Equal(DefaultJiraURL))
+			Expect(p.JiraProject).To(Equal("FOO"))
+			Expect(p.Watchers).To(Equal([]string{"jdoe"}))
+		})
+	})
+
+	Describe("Validate", func() {
+		It("should accept a complete profile", func() {
+			p := &Profile{
+				GithubProject: "operator-framework/operator-sdk",
+				JiraURL:       "https://issues.redhat.com",
+				JiraProject:   "OSDK",
+				JiraAuth:      "basic",
+				JiraUser:      "jane@example.com",
+			}
+			Expect(p.Validate()).To(Succeed())
+		})
+		It("should reject a github project without a repo", func() {
+			p := &Profile{GithubProject: "operator-framework"}
+			Expect(p.Validate()).To(MatchError(`invalid github project "operator-framework", must be ORG/REPO`))
+		})
+		It("should reject a jira url without a scheme", func() {
+			p := &Profile{JiraURL: "issues.redhat.com"}
+			Expect(p.Validate()).To(MatchError(`invalid jira url "issues.redhat.com", must be an http or https URL`))
+		})
+		It("should reject a token source without a source", func() {
+			p := &Profile{JiraTokenSource: &token.SourceConfig{}}
+			Expect(p.Validate()).To(MatchError("jira token source: a token source must set exactly one of " +
+				"command, keyring or encryptedFile"))
+		})
+		It("should reject basic auth without a user", func() {
+			p := &Profile{JiraAuth: "basic"}
+			Expect(p.Validate()).To(MatchError("basic auth requires a jira user"))
+		})
+	})
+
+	Describe("Write", func() {
+		It("should write the file readable only by the user", func() {
+			path := filepath.Join(dir, "gh2jira", "config.yaml")
+			c := &Config{Profiles: map[string]*Profile{
+				DefaultProfile: {GithubProject: "foo/bar", JiraProject: "FOO"},
+			}}
+			Expect(c.Write(path, false)).To(Succeed())
+
+			fi, err := os.Stat(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fi.Mode().Perm()).To(Equal(os.FileMode(0o600)))
+			data, err := os.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(Equal("profiles:\n" +
+				"    default:\n" +
+				"        githubProject: foo/bar\n" +
+				"        jiraProject: FOO\n"))
+		})
+		It("should not overwrite an existing file", func() {
+			path := filepath.Join(dir, "tokens.yaml")
+			Expect(os.WriteFile(path, []byte("keep"), 0o600)).To(Succeed())
+
+			err := WriteFile(path, []byte("new"), false)
+			Expect(err).To(MatchError(fs.ErrExist))
+			data, err := os.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(Equal("keep"))
+		})
+		It("should overwrite an existing file if forced", func() {
+			path := filepath.Join(dir, "tokens.yaml")
+			Expect(os.WriteFile(path, []byte("an older and longer file"), 0o644)).To(Succeed())
+
+			Expect(WriteFile(path, []byte("new"), true)).To(Succeed())
+			fi, err := os.Stat(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fi.Mode().Perm()).To(Equal(os.FileMode(0o600)))
+			data, err := os.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(Equal("new"))
+		})
+	})
+})
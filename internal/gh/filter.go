@@ -0,0 +1,119 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gh
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v47/github"
+)
+
+// Filter selects issues by what the issues API cannot express: excluded
+// labels and matching any rather than all labels. It also covers the
+// assignee and milestone exclusions so a search result can be filtered the
+// same way as a repo listing.
+type Filter struct {
+	// Labels the issue must have, all of them unless AnyLabel is set.
+	Labels   []string
+	AnyLabel bool
+	// ExcludeLabels the issue must not have.
+	ExcludeLabels []string
+	// NoAssignee and NoMilestone only select issues without one.
+	NoAssignee  bool
+	NoMilestone bool
+}
+
+// Match tells whether the issue passes the filter. Labels are matched
+// ignoring case, like Github does.
+func (f *Filter) Match(issue *github.Issue) bool {
+	if f == nil {
+		return true
+	}
+	for _, l := range f.ExcludeLabels {
+		if hasLabel(issue, l) {
+			return false
+		}
+	}
+	if len(f.Labels) > 0 {
+		matched := 0
+		for _, l := range f.Labels {
+			if hasLabel(issue, l) {
+				matched++
+			}
+		}
+		if f.AnyLabel && matched == 0 || !f.AnyLabel && matched < len(f.Labels) {
+			return false
+		}
+	}
+	if f.NoAssignee && (issue.Assignee != nil || len(issue.Assignees) > 0) {
+		return false
+	}
+	if f.NoMilestone && issue.Milestone != nil {
+		return false
+	}
+	return true
+}
+
+// hasLabel tells whether the issue has the label, ignoring case.
+func hasLabel(issue *github.Issue, name string) bool {
+	for _, l := range issue.Labels {
+		if strings.EqualFold(l.GetName(), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply returns the issues that pass the filter.
+func (f *Filter) Apply(issues []*github.Issue) []*github.Issue {
+	var filtered []*github.Issue
+	for _, issue := range issues {
+		if f.Match(issue) {
+			filtered = append(filtered, issue)
+		}
+	}
+	return filtered
+}
+
+// filter returns the client side filter of the config.
+func (c *ListerConfig) filter() *Filter {
+	return &Filter{
+		Labels:        c.Label,
+		AnyLabel:      c.AnyLabel,
+		ExcludeLabels: c.ExcludeLabel,
+		NoAssignee:    c.NoAssignee,
+		NoMilestone:   c.NoMilestone,
+	}
+}
+
+// validateFilter catches contradicting filters.
+func (c *ListerConfig) validateFilter() error {
+	if c.NoAssignee && c.Assignee != "" && c.Assignee != "none" {
+		return errors.New("cannot filter by assignee and no assignee at the same time")
+	}
+	if c.NoMilestone && c.Milestone != "" && c.Milestone != "none" {
+		return errors.New("cannot filter by milestone and no milestone at the same time")
+	}
+	for _, l := range c.ExcludeLabel {
+		for _, m := range c.Label {
+			if strings.EqualFold(l, m) {
+				return fmt.Errorf("label %s is both required and excluded", l)
+			}
+		}
+	}
+	return nil
+}
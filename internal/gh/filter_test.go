@@ -0,0 +1,125 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gh
+
+import (
+	"net/http"
+
+	"github.com/google/go-github/v47/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func labeled(number int, labels ...string) *github.Issue {
+	issue := &github.Issue{Number: github.Int(number)}
+	for _, l := range labels {
+		issue.Labels = append(issue.Labels, &github.Label{Name: github.String(l)})
+	}
+	return issue
+}
+
+func numbers(issues []*github.Issue) []int {
+	var n []int
+	for _, i := range issues {
+		n = append(n, i.GetNumber())
+	}
+	return n
+}
+
+var _ = Describe("Filter", func() {
+	issues := []*github.Issue{
+		labeled(1, "kind/bug"),
+		labeled(2, "kind/bug", "lifecycle/stale"),
+		labeled(3, "kind/feature", "jira/tracked"),
+		labeled(4),
+	}
+
+	Describe("Apply", func() {
+		It("should keep everything without criteria", func() {
+			var f *Filter
+			Expect(f.Apply(issues)).To(HaveLen(4))
+			Expect((&Filter{}).Apply(issues)).To(HaveLen(4))
+		})
+		It("should require all labels", func() {
+			f := &Filter{Labels: []string{"kind/bug", "lifecycle/stale"}}
+			Expect(numbers(f.Apply(issues))).To(Equal([]int{2}))
+		})
+		It("should require any label", func() {
+			f := &Filter{Labels: []string{"kind/bug", "kind/feature"}, AnyLabel: true}
+			Expect(numbers(f.Apply(issues))).To(Equal([]int{1, 2, 3}))
+		})
+		It("should exclude labels", func() {
+			f := &Filter{
+				Labels:        []string{"kind/bug"},
+				ExcludeLabels: []string{"lifecycle/stale", "jira/tracked"},
+			}
+			Expect(numbers(f.Apply(issues))).To(Equal([]int{1}))
+		})
+		It("should ignore the case of labels", func() {
+			f := &Filter{Labels: []string{"Kind/Bug"}, ExcludeLabels: []string{"LIFECYCLE/stale"}}
+			Expect(numbers(f.Apply(issues))).To(Equal([]int{1}))
+		})
+		It("should skip assigned issues", func() {
+			f := &Filter{NoAssignee: true}
+			assigned := &github.Issue{Number: github.Int(5),
+				Assignees: []*github.User{{Login: github.String("johndoe")}}}
+			Expect(numbers(f.Apply([]*github.Issue{issues[0], assigned}))).To(Equal([]int{1}))
+		})
+		It("should skip issues with a milestone", func() {
+			f := &Filter{NoMilestone: true}
+			planned := &github.Issue{Number: github.Int(5), Milestone: &github.Milestone{}}
+			Expect(numbers(f.Apply([]*github.Issue{issues[0], planned}))).To(Equal([]int{1}))
+		})
+	})
+
+	Describe("ListIssues", func() {
+		It("should filter on the client and ask the API for what it can", func() {
+			var query map[string][]string
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(
+					mock.GetReposIssuesByOwnerByRepo,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						query = r.URL.Query()
+						w.Write(mock.MustMarshal(issues))
+					}),
+				),
+			)
+			iss, err := ListIssues(WithClient(mockedHTTPClient), WithProject("foo/bar"),
+				WithLabel([]string{"kind/bug", "kind/feature"}),
+				WithAnyLabel(true),
+				WithExcludeLabel([]string{"lifecycle/stale"}),
+				WithNoAssignee(true),
+				WithNoMilestone(true),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(numbers(iss)).To(Equal([]int{1, 3}))
+			Expect(query).NotTo(HaveKey("labels"))
+			Expect(query["assignee"]).To(Equal([]string{"none"}))
+			Expect(query["milestone"]).To(Equal([]string{"none"}))
+		})
+		It("should reject contradicting filters", func() {
+			_, err := ListIssues(WithClient(mock.NewMockedHTTPClient()), WithProject("foo/bar"),
+				WithAssignee("johndoe"), WithNoAssignee(true))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("no assignee"))
+
+			_, err = ListIssues(WithClient(mock.NewMockedHTTPClient()), WithProject("foo/bar"),
+				WithLabel([]string{"kind/bug"}), WithExcludeLabel([]string{"kind/bug"}))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("both required and excluded"))
+		})
+	})
+})
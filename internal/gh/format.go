@@ -0,0 +1,290 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gh
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/google/go-github/v47/github"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jmrodri/gh2jira/internal/redact"
+)
+
+// Output formats of WriteIssues.
+const (
+	FormatOneline  = "oneline"
+	FormatTable    = "table"
+	FormatJSON     = "json"
+	FormatYAML     = "yaml"
+	FormatCSV      = "csv"
+	FormatTemplate = "template"
+)
+
+// Schemas of the JSON and YAML formats.
+const (
+	// SchemaSummary writes IssueSummary objects.
+	SchemaSummary = "summary"
+	// SchemaFull writes the issues as returned by the Github API.
+	SchemaFull = "full"
+)
+
+// Formats are the supported output formats.
+var Formats = []string{FormatOneline, FormatTable, FormatJSON, FormatYAML, FormatCSV, FormatTemplate}
+
+// DefaultColumns are the columns of the table and CSV formats.
+var DefaultColumns = []string{"repo", "number", "state", "assignee", "milestone", "title"}
+
+// IssueSummary is the trimmed down issue of the JSON, YAML, CSV and table
+// formats. Templates are given the full github.Issue.
+type IssueSummary struct {
+	Repo      string    `json:"repo" yaml:"repo"`
+	Number    int       `json:"number" yaml:"number"`
+	State     string    `json:"state" yaml:"state"`
+	Title     string    `json:"title" yaml:"title"`
+	Author    string    `json:"author" yaml:"author"`
+	Assignees []string  `json:"assignees" yaml:"assignees"`
+	Labels    []string  `json:"labels" yaml:"labels"`
+	Milestone string    `json:"milestone,omitempty" yaml:"milestone,omitempty"`
+	Comments  int       `json:"comments" yaml:"comments"`
+	CreatedAt time.Time `json:"createdAt" yaml:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" yaml:"updatedAt"`
+	URL       string    `json:"url" yaml:"url"`
+}
+
+// Summarize trims the issue down to an IssueSummary.
+func Summarize(issue *github.Issue) IssueSummary {
+	org, repo := IssueRepo(issue)
+	s := IssueSummary{
+		Number:    issue.GetNumber(),
+		State:     issue.GetState(),
+		Title:     issue.GetTitle(),
+		Author:    issue.GetUser().GetLogin(),
+		Assignees: []string{},
+		Labels:    []string{},
+		Milestone: issue.GetMilestone().GetTitle(),
+		Comments:  issue.GetComments(),
+		CreatedAt: issue.GetCreatedAt(),
+		UpdatedAt: issue.GetUpdatedAt(),
+		URL:       issue.GetHTMLURL(),
+	}
+	if org != "" {
+		s.Repo = org + "/" + repo
+	}
+	for _, a := range issue.Assignees {
+		s.Assignees = append(s.Assignees, a.GetLogin())
+	}
+	if len(s.Assignees) == 0 && issue.Assignee != nil {
+		s.Assignees = append(s.Assignees, issue.Assignee.GetLogin())
+	}
+	for _, l := range issue.Labels {
+		s.Labels = append(s.Labels, l.GetName())
+	}
+	return s
+}
+
+// columns maps the column names to their value in an IssueSummary.
+var columns = map[string]func(s *IssueSummary) string{
+	"repo":      func(s *IssueSummary) string { return s.Repo },
+	"number":    func(s *IssueSummary) string { return strconv.Itoa(s.Number) },
+	"state":     func(s *IssueSummary) string { return s.State },
+	"title":     func(s *IssueSummary) string { return s.Title },
+	"author":    func(s *IssueSummary) string { return s.Author },
+	"assignee":  func(s *IssueSummary) string { return strings.Join(s.Assignees, ",") },
+	"labels":    func(s *IssueSummary) string { return strings.Join(s.Labels, ",") },
+	"milestone": func(s *IssueSummary) string { return s.Milestone },
+	"comments":  func(s *IssueSummary) string { return strconv.Itoa(s.Comments) },
+	"created":   func(s *IssueSummary) string { return formatTime(s.CreatedAt) },
+	"updated":   func(s *IssueSummary) string { return formatTime(s.UpdatedAt) },
+	"url":       func(s *IssueSummary) string { return s.URL },
+}
+
+// Columns returns the names of the available table and CSV columns.
+func Columns() []string {
+	var names []string
+	for name := range columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// OutputOptions configures WriteIssues.
+type OutputOptions struct {
+	// Format is one of Formats.
+	Format string
+	// Columns of the table and CSV formats, DefaultColumns if empty.
+	Columns []string
+	// Schema of the JSON and YAML formats, SchemaSummary if empty.
+	Schema string
+	// Template is the Go template executed for every issue of the template
+	// format.
+	Template string
+}
+
+// Validate checks the options before anything is fetched.
+func (o *OutputOptions) Validate() error {
+	if err := oneOf("output", o.Format, Formats...); err != nil {
+		return err
+	}
+	if err := oneOf("schema", o.Schema, SchemaSummary, SchemaFull); err != nil {
+		return err
+	}
+	for _, c := range o.Columns {
+		if _, ok := columns[c]; !ok {
+			return fmt.Errorf("invalid column %q, must be one of %s", c, strings.Join(Columns(), ", "))
+		}
+	}
+	if o.Format == FormatTemplate {
+		if o.Template == "" {
+			return fmt.Errorf("the template output requires a template")
+		}
+		if _, err := o.template(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *OutputOptions) template() (*template.Template, error) {
+	t, err := template.New("issue").Funcs(template.FuncMap{
+		"summary": Summarize,
+		"join":    strings.Join,
+	}).Parse(o.Template)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return t, nil
+}
+
+// WriteIssues writes the issues to w in the given output format. The oneline
+// format is written by PrintGithubIssue instead.
+func WriteIssues(w io.Writer, issues []*github.Issue, o *OutputOptions) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	w = redact.Writer(w)
+	cols := o.Columns
+	if len(cols) == 0 {
+		cols = DefaultColumns
+	}
+
+	switch o.Format {
+	case FormatTable:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.ToUpper(strings.Join(cols, "\t")))
+		for _, issue := range issues {
+			fmt.Fprintln(tw, strings.Join(row(issue, cols), "\t"))
+		}
+		return tw.Flush()
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(cols); err != nil {
+			return err
+		}
+		for _, issue := range issues {
+			if err := cw.Write(row(issue, cols)); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(o.objects(issues))
+	case FormatYAML:
+		v, err := yamlValue(o.objects(issues))
+		if err != nil {
+			return err
+		}
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+	case FormatTemplate:
+		t, err := o.template()
+		if err != nil {
+			return err
+		}
+		for _, issue := range issues {
+			if err := t.Execute(w, issue); err != nil {
+				return err
+			}
+			if !strings.HasSuffix(o.Template, "\n") {
+				fmt.Fprintln(w)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("output %q is not written by WriteIssues", o.Format)
+	}
+}
+
+func row(issue *github.Issue, cols []string) []string {
+	s := Summarize(issue)
+	values := make([]string, len(cols))
+	for i, c := range cols {
+		values[i] = columns[c](&s)
+	}
+	return values
+}
+
+// objects returns the issues in the JSON and YAML schema.
+func (o *OutputOptions) objects(issues []*github.Issue) interface{} {
+	if o.Schema == SchemaFull {
+		if issues == nil {
+			return []*github.Issue{}
+		}
+		return issues
+	}
+	summaries := []IssueSummary{}
+	for _, issue := range issues {
+		summaries = append(summaries, Summarize(issue))
+	}
+	return summaries
+}
+
+// yamlValue round trips v through JSON so the YAML has the same keys as the
+// JSON output, e.g. html_url rather than htmlurl.
+func yamlValue(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,136 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gh
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/google/go-github/v47/github"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v3"
+)
+
+var _ = Describe("Format", func() {
+	var issues []*github.Issue
+	BeforeEach(func() {
+		issues = []*github.Issue{
+			{
+				Number:        github.Int(123),
+				Title:         github.String("Issue 1, with a comma"),
+				State:         github.String("open"),
+				RepositoryURL: github.String("https://api.github.com/repos/foo/bar"),
+				HTMLURL:       github.String("https://github.com/foo/bar/issues/123"),
+				User:          &github.User{Login: github.String("johndoe")},
+				Assignees:     []*github.User{{Login: github.String("jmrodri")}},
+				Labels:        []*github.Label{{Name: github.String("kind/bug")}},
+				Milestone:     &github.Milestone{Title: github.String("v1.27.0")},
+				CreatedAt:     func() *time.Time { t := time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC); return &t }(),
+			},
+			{
+				Number: github.Int(124),
+				Title:  github.String("Issue 2"),
+				State:  github.String("closed"),
+			},
+		}
+	})
+
+	Describe("Summarize", func() {
+		It("should handle missing fields", func() {
+			s := Summarize(issues[1])
+			Expect(s.Repo).To(BeEmpty())
+			Expect(s.Assignees).To(BeEmpty())
+			Expect(s.Milestone).To(BeEmpty())
+		})
+		It("should fall back to the single assignee", func() {
+			s := Summarize(&github.Issue{Assignee: &github.User{Login: github.String("johndoe")}})
+			Expect(s.Assignees).To(Equal([]string{"johndoe"}))
+		})
+	})
+
+	Describe("OutputOptions", func() {
+		It("should reject unknown formats and columns", func() {
+			Expect((&OutputOptions{Format: "xml"}).Validate()).NotTo(Succeed())
+			Expect((&OutputOptions{Format: FormatTable, Columns: []string{"color"}}).Validate()).NotTo(Succeed())
+			Expect((&OutputOptions{Format: FormatJSON, Schema: "partial"}).Validate()).NotTo(Succeed())
+		})
+		It("should reject bad templates", func() {
+			Expect((&OutputOptions{Format: FormatTemplate}).Validate()).NotTo(Succeed())
+			Expect((&OutputOptions{Format: FormatTemplate, Template: "{{.Number"}).Validate()).NotTo(Succeed())
+		})
+	})
+
+	Describe("WriteIssues", func() {
+		It("should write an aligned table", func() {
+			var out bytes.Buffer
+			err := WriteIssues(&out, issues, &OutputOptions{
+				Format:  FormatTable,
+				Columns: []string{"number", "state", "title"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out.String()).To(Equal(
+				"NUMBER  STATE   TITLE\n" +
+					"123     open    Issue 1, with a comma\n" +
+					"124     closed  Issue 2\n"))
+		})
+		It("should write csv", func() {
+			var out bytes.Buffer
+			err := WriteIssues(&out, issues, &OutputOptions{Format: FormatCSV})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out.String()).To(Equal(
+				"repo,number,state,assignee,milestone,title\n" +
+					"foo/bar,123,open,jmrodri,v1.27.0,\"Issue 1, with a comma\"\n" +
+					",124,closed,,,Issue 2\n"))
+		})
+		It("should write the summary as json", func() {
+			var out bytes.Buffer
+			err := WriteIssues(&out, issues, &OutputOptions{Format: FormatJSON})
+			Expect(err).NotTo(HaveOccurred())
+			var summaries []IssueSummary
+			Expect(json.Unmarshal(out.Bytes(), &summaries)).To(Succeed())
+			Expect(summaries).To(HaveLen(2))
+			Expect(summaries[0].Labels).To(Equal([]string{"kind/bug"}))
+			Expect(summaries[0].Author).To(Equal("johndoe"))
+		})
+		It("should write an empty json list", func() {
+			var out bytes.Buffer
+			Expect(WriteIssues(&out, nil, &OutputOptions{Format: FormatJSON})).To(Succeed())
+			Expect(out.String()).To(Equal("[]\n"))
+			out.Reset()
+			Expect(WriteIssues(&out, nil, &OutputOptions{Format: FormatJSON, Schema: SchemaFull})).To(Succeed())
+			Expect(out.String()).To(Equal("[]\n"))
+		})
+		It("should write full issues as yaml with the api field names", func() {
+			var out bytes.Buffer
+			err := WriteIssues(&out, issues, &OutputOptions{Format: FormatYAML, Schema: SchemaFull})
+			Expect(err).NotTo(HaveOccurred())
+			var full []map[string]interface{}
+			Expect(yaml.Unmarshal(out.Bytes(), &full)).To(Succeed())
+			Expect(full).To(HaveLen(2))
+			Expect(full[0]).To(HaveKeyWithValue("html_url", "https://github.com/foo/bar/issues/123"))
+		})
+		It("should execute the template for every issue", func() {
+			var out bytes.Buffer
+			err := WriteIssues(&out, issues, &OutputOptions{
+				Format:   FormatTemplate,
+				Template: `{{.Number}}: {{.Title}} [{{join (summary .).Labels ","}}]`,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out.String()).To(Equal("123: Issue 1, with a comma [kind/bug]\n124: Issue 2 []\n"))
+		})
+	})
+})
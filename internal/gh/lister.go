@@ -17,11 +17,18 @@ package gh
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"strings"
+	"time"
 
 	"github.com/google/go-github/v47/github"
 	"golang.org/x/oauth2"
+
+	"github.com/jmrodri/gh2jira/internal/redact"
+	"github.com/jmrodri/gh2jira/internal/transport"
 )
 
 type Option func(*ListerConfig) error
@@ -32,7 +39,21 @@ type ListerConfig struct {
 	Token     string
 	Assignee  string
 	Project   string
+	Projects  []string
 	Label     []string
+	State     string
+
+	AnyLabel     bool
+	ExcludeLabel []string
+	NoAssignee   bool
+	NoMilestone  bool
+
+	Since     time.Time
+	Sort      string
+	Direction string
+	GithubURL string
+	transport *transport.Config
+	warnings  io.Writer
 }
 
 func (c *ListerConfig) setDefaults() error {
@@ -41,14 +62,43 @@ func (c *ListerConfig) setDefaults() error {
 		if c.Token == "" {
 			return errors.New("cannot create github client without a token")
 		}
+		t, err := c.transport.Transport()
+		if err != nil {
+			return err
+		}
+		// errors quoting the request must not leak the token
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: redact.Transport(t)})
 		ts := oauth2.StaticTokenSource(
 			&oauth2.Token{AccessToken: c.Token},
 		)
 		c.client = oauth2.NewClient(ctx, ts)
 	}
+	if c.State == "" {
+		c.State = "open"
+	}
+	if c.warnings == nil {
+		c.warnings = redact.Stderr
+	}
 	return nil
 }
 
+// newClient returns a client for api.github.com, or for the Github Enterprise
+// Server at GithubURL if one is set.
+func (c *ListerConfig) newClient() (*github.Client, error) {
+	if c.GithubURL == "" {
+		return github.NewClient(c.client), nil
+	}
+	u, err := url.Parse(c.GithubURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == "github.com" || u.Host == "api.github.com" {
+		return github.NewClient(c.client), nil
+	}
+	base := strings.TrimSuffix(c.GithubURL, "/")
+	return github.NewEnterpriseClient(base+"/api/v3/", base+"/api/uploads/", c.client)
+}
+
 func (l *ListerConfig) GetGithubOrg() string {
 	return strings.Split(l.Project, "/")[0]
 }
@@ -75,6 +125,9 @@ func WithToken(token string) Option {
 	}
 }
 
+// WithMilestone filters issues by milestone: a milestone title, e.g. v1.27.0,
+// its number, "none" for issues without a milestone or "*" for issues with
+// any milestone.
 func WithMilestone(m string) Option {
 	return func(c *ListerConfig) error {
 		c.Milestone = m
@@ -96,6 +149,15 @@ func WithProject(p string) Option {
 	}
 }
 
+// WithProjects scopes SearchIssues to all the ORG/REPO projects instead of
+// the one set with WithProject.
+func WithProjects(p []string) Option {
+	return func(c *ListerConfig) error {
+		c.Projects = p
+		return nil
+	}
+}
+
 func WithLabel(l []string) Option {
 	return func(c *ListerConfig) error {
 		c.Label = l
@@ -103,6 +165,121 @@ func WithLabel(l []string) Option {
 	}
 }
 
+// WithAnyLabel lists issues with any rather than all of the labels given
+// with WithLabel.
+func WithAnyLabel(a bool) Option {
+	return func(c *ListerConfig) error {
+		c.AnyLabel = a
+		return nil
+	}
+}
+
+// WithExcludeLabel skips issues with any of the given labels.
+func WithExcludeLabel(l []string) Option {
+	return func(c *ListerConfig) error {
+		c.ExcludeLabel = l
+		return nil
+	}
+}
+
+// WithNoAssignee only lists issues nobody is assigned to.
+func WithNoAssignee(n bool) Option {
+	return func(c *ListerConfig) error {
+		c.NoAssignee = n
+		return nil
+	}
+}
+
+// WithNoMilestone only lists issues without a milestone.
+func WithNoMilestone(n bool) Option {
+	return func(c *ListerConfig) error {
+		c.NoMilestone = n
+		return nil
+	}
+}
+
+// WithState lists open, closed or all issues. Defaults to open.
+func WithState(s string) Option {
+	return func(c *ListerConfig) error {
+		if err := oneOf("state", s, "open", "closed", "all"); err != nil {
+			return err
+		}
+		c.State = s
+		return nil
+	}
+}
+
+// WithSince only lists issues updated at or after the given time.
+func WithSince(t time.Time) Option {
+	return func(c *ListerConfig) error {
+		c.Since = t
+		return nil
+	}
+}
+
+// WithSort sorts issues by created, updated or comments. Github defaults to
+// created.
+func WithSort(s string) Option {
+	return func(c *ListerConfig) error {
+		if err := oneOf("sort", s, "created", "updated", "comments"); err != nil {
+			return err
+		}
+		c.Sort = s
+		return nil
+	}
+}
+
+// WithDirection sets the sort direction, asc or desc. Github defaults to
+// desc.
+func WithDirection(d string) Option {
+	return func(c *ListerConfig) error {
+		if err := oneOf("direction", d, "asc", "desc"); err != nil {
+			return err
+		}
+		c.Direction = d
+		return nil
+	}
+}
+
+// oneOf returns an error unless value is empty or one of the allowed values.
+func oneOf(name, value string, allowed ...string) error {
+	if value == "" {
+		return nil
+	}
+	for _, a := range allowed {
+		if value == a {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid %s %q, must be one of %s", name, value, strings.Join(allowed, ", "))
+}
+
+// WithTransport sets the TLS and proxy settings used to reach Github. It has
+// no effect if a client is given with WithClient.
+func WithTransport(t *transport.Config) Option {
+	return func(c *ListerConfig) error {
+		c.transport = t
+		return nil
+	}
+}
+
+// WithWarnings sets where warnings go, stderr by default.
+func WithWarnings(w io.Writer) Option {
+	return func(c *ListerConfig) error {
+		c.warnings = w
+		return nil
+	}
+}
+
+// WithGithubURL sets the URL of a Github Enterprise Server instance, e.g.
+// https://github.example.com. By default api.github.com is used.
+func WithGithubURL(u string) Option {
+	return func(c *ListerConfig) error {
+		c.GithubURL = u
+		return nil
+	}
+}
+
 func GetIssue(issueNum int, opts ...Option) (*github.Issue, error) {
 	config := ListerConfig{}
 	for _, opt := range opts {
@@ -115,7 +292,10 @@ func GetIssue(issueNum int, opts ...Option) (*github.Issue, error) {
 		return nil, err
 	}
 
-	client := github.NewClient(config.client)
+	client, err := config.newClient()
+	if err != nil {
+		return nil, err
+	}
 
 	issue, _, err := client.Issues.Get(context.Background(), config.GetGithubOrg(),
 		config.GetGithubRepo(), issueNum)
@@ -138,14 +318,41 @@ func ListIssues(opts ...Option) ([]*github.Issue, error) {
 		return nil, err
 	}
 
-	client := github.NewClient(config.client)
+	client, err := config.newClient()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := config.validateFilter(); err != nil {
+		return nil, err
+	}
+
+	milestone, err := resolveMilestone(client, config.GetGithubOrg(), config.GetGithubRepo(),
+		config.Milestone)
+	if err != nil {
+		return nil, err
+	}
 
 	opt := &github.IssueListByRepoOptions{
 		ListOptions: github.ListOptions{PerPage: 50},
-		State:       "open",
-		Milestone:   config.Milestone,
+		State:       config.State,
+		Milestone:   milestone,
 		Assignee:    config.Assignee,
 		Labels:      config.Label,
+		Since:       config.Since,
+		Sort:        config.Sort,
+		Direction:   config.Direction,
+	}
+	// let the API do as much of the filtering as it can
+	if config.NoMilestone {
+		opt.Milestone = "none"
+	}
+	if config.NoAssignee {
+		opt.Assignee = "none"
+	}
+	if config.AnyLabel {
+		// the API only matches all labels
+		opt.Labels = nil
 	}
 
 	var allIssues []*github.Issue
@@ -165,5 +372,99 @@ func ListIssues(opts ...Option) ([]*github.Issue, error) {
 		opt.Page = resp.NextPage
 	}
 
-	return allIssues, nil
+	return config.filter().Apply(allIssues), nil
+}
+
+// ListComments returns all the comments of the given issue.
+func ListComments(issueNum int, opts ...Option) ([]*github.IssueComment, error) {
+	config := ListerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.setDefaults(); err != nil {
+		return nil, err
+	}
+
+	client, err := config.newClient()
+	if err != nil {
+		return nil, err
+	}
+
+	opt := &github.IssueListCommentsOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	var allComments []*github.IssueComment
+
+	for {
+		comments, resp, err := client.Issues.ListComments(context.Background(),
+			config.GetGithubOrg(), config.GetGithubRepo(), issueNum, opt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		allComments = append(allComments, comments...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	return allComments, nil
+}
+
+// ListLinkedPullRequests returns the pull requests that reference the given
+// issue, e.g. the ones fixing it, as found in the issue timeline.
+func ListLinkedPullRequests(issueNum int, opts ...Option) ([]*github.Issue, error) {
+	config := ListerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.setDefaults(); err != nil {
+		return nil, err
+	}
+
+	client, err := config.newClient()
+	if err != nil {
+		return nil, err
+	}
+
+	opt := &github.ListOptions{PerPage: 100}
+
+	var prs []*github.Issue
+	seen := map[string]bool{}
+
+	for {
+		events, resp, err := client.Issues.ListIssueTimeline(context.Background(),
+			config.GetGithubOrg(), config.GetGithubRepo(), issueNum, opt)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range events {
+			if e.GetEvent() != "cross-referenced" || e.GetSource() == nil {
+				continue
+			}
+			pr := e.GetSource().GetIssue()
+			if pr == nil || !pr.IsPullRequest() || seen[pr.GetHTMLURL()] {
+				continue
+			}
+			seen[pr.GetHTMLURL()] = true
+			prs = append(prs, pr)
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	return prs, nil
 }
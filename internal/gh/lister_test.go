@@ -17,11 +17,15 @@ package gh
 import (
 	"fmt"
 	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/google/go-github/v47/github"
 	"github.com/migueleliasweb/go-github-mock/src/mock"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+
+	"github.com/jmrodri/gh2jira/internal/transport"
 )
 
 var _ = Describe("Lister", func() {
@@ -78,6 +82,32 @@ var _ = Describe("Lister", func() {
 		})
 	})
 
+	Context("newClient", func() {
+		It("should use api.github.com by default", func() {
+			options := ListerConfig{}
+			client, err := options.newClient()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.BaseURL.String()).To(Equal("https://api.github.com/"))
+
+			options.GithubURL = "https://github.com"
+			client, err = options.newClient()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.BaseURL.String()).To(Equal("https://api.github.com/"))
+		})
+		It("should use the enterprise api of a github enterprise server", func() {
+			options := ListerConfig{GithubURL: "https://github.example.com/"}
+			client, err := options.newClient()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.BaseURL.String()).To(Equal("https://github.example.com/api/v3/"))
+			Expect(client.UploadURL.String()).To(Equal("https://github.example.com/api/uploads/"))
+		})
+		It("should return an error for invalid urls", func() {
+			options := ListerConfig{GithubURL: "://nope"}
+			_, err := options.newClient()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
 	Context("With Option methods", func() {
 		var (
 			options ListerConfig
@@ -132,6 +162,78 @@ var _ = Describe("Lister", func() {
 				Expect(options.Project).To(Equal("operator-framework/operator-sdk"))
 			})
 		})
+		Describe("WithTransport", func() {
+			It("should set the transport config", func() {
+				t := &transport.Config{CABundle: "ca.pem"}
+				opt := WithTransport(t)
+				err := opt(&options)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(options.transport).To(Equal(t))
+			})
+			It("should fail to create a client with a bad transport", func() {
+				_, err := ListIssues(WithToken("token"),
+					WithTransport(&transport.Config{CABundle: "/does/not/exist.pem"}))
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("unable to read CA bundle"))
+			})
+		})
+		Describe("WithGithubURL", func() {
+			It("should set the github url", func() {
+				opt := WithGithubURL("https://github.example.com")
+				err := opt(&options)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(options.GithubURL).To(Equal("https://github.example.com"))
+			})
+		})
+		Describe("WithState", func() {
+			It("should set the state", func() {
+				opt := WithState("closed")
+				err := opt(&options)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(options.State).To(Equal("closed"))
+			})
+			It("should reject unknown states", func() {
+				opt := WithState("merged")
+				err := opt(&options)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring(`invalid state "merged"`))
+			})
+		})
+		Describe("WithSince", func() {
+			It("should set since", func() {
+				since := time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)
+				opt := WithSince(since)
+				err := opt(&options)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(options.Since).To(Equal(since))
+			})
+		})
+		Describe("WithSort", func() {
+			It("should set the sort", func() {
+				opt := WithSort("comments")
+				err := opt(&options)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(options.Sort).To(Equal("comments"))
+			})
+			It("should reject unknown sorts", func() {
+				opt := WithSort("title")
+				err := opt(&options)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+		Describe("WithDirection", func() {
+			It("should set the direction", func() {
+				opt := WithDirection("asc")
+				err := opt(&options)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(options.Direction).To(Equal("asc"))
+			})
+			It("should reject unknown directions", func() {
+				opt := WithDirection("up")
+				err := opt(&options)
+				Expect(err).To(HaveOccurred())
+			})
+		})
 		Describe("WithLabel", func() {
 			It("should set the label", func() {
 				labels := []string{"kind/bug", "documentation"}
@@ -172,6 +274,44 @@ var _ = Describe("Lister", func() {
 			Expect(len(iss)).To(Equal(2))
 			Expect(err).NotTo(HaveOccurred())
 		})
+		It("should pass the state, since and sort to github", func() {
+			var query url.Values
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(
+					mock.GetReposIssuesByOwnerByRepo,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						query = r.URL.Query()
+						w.Write([]byte("[]"))
+					}),
+				),
+			)
+			_, err := ListIssues(WithClient(mockedHTTPClient), WithProject("fakeorg/fakeproject"),
+				WithState("all"),
+				WithSince(time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)),
+				WithSort("updated"),
+				WithDirection("asc"),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(query.Get("state")).To(Equal("all"))
+			Expect(query.Get("since")).To(Equal("2022-09-01T00:00:00Z"))
+			Expect(query.Get("sort")).To(Equal("updated"))
+			Expect(query.Get("direction")).To(Equal("asc"))
+		})
+		It("should list open issues by default", func() {
+			var state string
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(
+					mock.GetReposIssuesByOwnerByRepo,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						state = r.URL.Query().Get("state")
+						w.Write([]byte("[]"))
+					}),
+				),
+			)
+			_, err := ListIssues(WithClient(mockedHTTPClient), WithProject("fakeorg/fakeproject"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state).To(Equal("open"))
+		})
 		It("should return error if list fails", func() {
 			// if our request returns an error ListIssues should return
 			// that error
@@ -231,4 +371,56 @@ var _ = Describe("Lister", func() {
 			Expect(err).NotTo(HaveOccurred())
 		})
 	})
+	Describe("ListComments", func() {
+		It("should return an error if there is no token", func() {
+			c, err := ListComments(10)
+			Expect(c).To(BeNil())
+			Expect(err).To(HaveOccurred())
+		})
+		It("should return all the comments", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetReposIssuesCommentsByOwnerByRepoByIssueNumber,
+					[]github.IssueComment{
+						{User: &github.User{Login: github.String("johndoe")}},
+						{User: &github.User{Login: github.String("janedoe")}},
+					},
+				),
+			)
+			c, err := ListComments(10, WithClient(mockedHTTPClient),
+				WithProject("fakeorg/fakeproject"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c).To(HaveLen(2))
+			Expect(c[1].GetUser().GetLogin()).To(Equal("janedoe"))
+		})
+	})
+
+	Describe("ListLinkedPullRequests", func() {
+		It("should return an error if there is no token", func() {
+			prs, err := ListLinkedPullRequests(1)
+			Expect(prs).To(BeNil())
+			Expect(err).To(HaveOccurred())
+		})
+		It("should return the pull requests referencing the issue once", func() {
+			pr := &github.Issue{
+				Number:           github.Int(124),
+				HTMLURL:          github.String("https://github.com/foo/bar/pull/124"),
+				PullRequestLinks: &github.PullRequestLinks{URL: github.String("https://api.github.com/repos/foo/bar/pulls/124")},
+			}
+			other := &github.Issue{Number: github.Int(125), HTMLURL: github.String("https://github.com/foo/bar/issues/125")}
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetReposIssuesTimelineByOwnerByRepoByIssueNumber,
+					[]github.Timeline{
+						{Event: github.String("labeled")},
+						{Event: github.String("cross-referenced"), Source: &github.Source{Issue: pr}},
+						{Event: github.String("cross-referenced"), Source: &github.Source{Issue: other}},
+						{Event: github.String("cross-referenced"), Source: &github.Source{Issue: pr}},
+					},
+				),
+			)
+			prs, err := ListLinkedPullRequests(123, WithClient(mockedHTTPClient), WithProject("foo/bar"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(prs).To(HaveLen(1))
+			Expect(prs[0].GetNumber()).To(Equal(124))
+		})
+	})
 })
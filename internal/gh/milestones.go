@@ -0,0 +1,141 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v47/github"
+)
+
+// maxSuggestions is how many close milestone titles are suggested when a
+// title is not found.
+const maxSuggestions = 3
+
+// resolveMilestone returns the milestone filter the issues API expects: a
+// milestone number, "none" or "*". Milestone titles are looked up among the
+// open and closed milestones of the repo. A number is only taken as a
+// milestone number if no milestone has it as its title, e.g. 2023.
+func resolveMilestone(client *github.Client, org, repo, milestone string) (string, error) {
+	if milestone == "" || milestone == "none" || milestone == "*" {
+		return milestone, nil
+	}
+
+	opt := &github.MilestoneListOptions{
+		State:       "all",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	var titles []string
+	numbers := map[string]int{}
+	for {
+		milestones, resp, err := client.Issues.ListMilestones(context.Background(), org, repo, opt)
+		if err != nil {
+			return "", fmt.Errorf("unable to look up milestone %q: %w", milestone, err)
+		}
+		for _, m := range milestones {
+			if m.GetTitle() == milestone {
+				return strconv.Itoa(m.GetNumber()), nil
+			}
+			titles = append(titles, m.GetTitle())
+			numbers[m.GetTitle()] = m.GetNumber()
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	// be lenient about case, but only if it is not ambiguous
+	var folded []string
+	for _, t := range titles {
+		if strings.EqualFold(t, milestone) {
+			folded = append(folded, t)
+		}
+	}
+	if len(folded) == 1 {
+		return strconv.Itoa(numbers[folded[0]]), nil
+	}
+	if _, err := strconv.Atoi(milestone); err == nil {
+		return milestone, nil
+	}
+
+	msg := fmt.Sprintf("milestone %q not found in %s/%s", milestone, org, repo)
+	if closest := closestMatches(milestone, titles, maxSuggestions); len(closest) > 0 {
+		msg += fmt.Sprintf(", did you mean: %s", strings.Join(closest, ", "))
+	}
+	return "", errors.New(msg)
+}
+
+// closestMatches returns up to n candidates closest to s by edit distance.
+func closestMatches(s string, candidates []string, n int) []string {
+	type match struct {
+		title    string
+		distance int
+	}
+	var matches []match
+	for _, c := range candidates {
+		d := levenshtein(strings.ToLower(s), strings.ToLower(c))
+		// skip candidates that have next to nothing in common with s
+		if d > len(s) && d > len(c)/2 {
+			continue
+		}
+		matches = append(matches, match{c, d})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].distance < matches[j].distance
+	})
+	var closest []string
+	for i := 0; i < len(matches) && i < n; i++ {
+		closest = append(closest, matches[i].title)
+	}
+	return closest
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+func minInt(first int, rest ...int) int {
+	m := first
+	for _, v := range rest {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
@@ -0,0 +1,106 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gh
+
+import (
+	"net/http"
+
+	"github.com/google/go-github/v47/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Milestones", func() {
+	milestones := []github.Milestone{
+		{Number: github.Int(12), Title: github.String("v1.26.0")},
+		{Number: github.Int(13), Title: github.String("v1.27.0")},
+		{Number: github.Int(14), Title: github.String("Backlog")},
+	}
+
+	Describe("resolveMilestone", func() {
+		It("should pass none and * through", func() {
+			// no milestones endpoint, any request would fail
+			client := github.NewClient(mock.NewMockedHTTPClient())
+			for _, m := range []string{"", "none", "*"} {
+				resolved, err := resolveMilestone(client, "foo", "bar", m)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resolved).To(Equal(m))
+			}
+		})
+		It("should pass numbers that are not a title through", func() {
+			client := github.NewClient(mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetReposMilestonesByOwnerByRepo, milestones),
+			))
+			resolved, err := resolveMilestone(client, "foo", "bar", "12")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resolved).To(Equal("12"))
+		})
+		It("should prefer a title to a number", func() {
+			years := []github.Milestone{
+				{Number: github.Int(3), Title: github.String("2023")},
+			}
+			client := github.NewClient(mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetReposMilestonesByOwnerByRepo, years),
+			))
+			resolved, err := resolveMilestone(client, "foo", "bar", "2023")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resolved).To(Equal("3"))
+		})
+		It("should resolve a title to its number", func() {
+			client := github.NewClient(mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetReposMilestonesByOwnerByRepo, milestones),
+			))
+			resolved, err := resolveMilestone(client, "foo", "bar", "v1.27.0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resolved).To(Equal("13"))
+		})
+		It("should ignore case when the title is unambiguous", func() {
+			client := github.NewClient(mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetReposMilestonesByOwnerByRepo, milestones),
+			))
+			resolved, err := resolveMilestone(client, "foo", "bar", "backlog")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resolved).To(Equal("14"))
+		})
+		It("should list the closest matches of an unknown title", func() {
+			client := github.NewClient(mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetReposMilestonesByOwnerByRepo, milestones),
+			))
+			_, err := resolveMilestone(client, "foo", "bar", "v1.27")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(`milestone "v1.27" not found in foo/bar, did you mean: v1.27.0, v1.26.0`))
+		})
+		It("should return an error if the milestones cannot be listed", func() {
+			client := github.NewClient(mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(
+					mock.GetReposMilestonesByOwnerByRepo,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						mock.WriteError(w, http.StatusInternalServerError, "boom")
+					}),
+				),
+			))
+			_, err := resolveMilestone(client, "foo", "bar", "v1.27.0")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(`unable to look up milestone "v1.27.0"`))
+		})
+	})
+
+	Describe("closestMatches", func() {
+		It("should skip unrelated titles", func() {
+			Expect(closestMatches("v2", []string{"Backlog", "v1"}, 3)).To(Equal([]string{"v1"}))
+		})
+	})
+})
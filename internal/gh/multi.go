@@ -0,0 +1,205 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gh
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/go-github/v47/github"
+)
+
+// maxConcurrency is how many repos are listed at the same time.
+const maxConcurrency = 4
+
+var (
+	issueRefRegex = regexp.MustCompile(`^(?:([\w.-]+)/([\w.-]+))?#?(\d+)$`)
+	issueURLRegex = regexp.MustCompile(`^https?://[^\s/]+/([\w.-]+)/([\w.-]+)/issues/(\d+)/?$`)
+)
+
+// ParseIssueRef parses an issue given as a number, #number, ORG/REPO#number or
+// the issue URL. The project is empty unless the reference names one.
+func ParseIssueRef(ref string) (project string, number int, err error) {
+	ref = strings.TrimSpace(ref)
+	if m := issueURLRegex.FindStringSubmatch(ref); m != nil {
+		number, _ = strconv.Atoi(m[3])
+		return m[1] + "/" + m[2], number, nil
+	}
+	m := issueRefRegex.FindStringSubmatch(ref)
+	if m == nil {
+		return "", 0, fmt.Errorf("invalid issue %q, expected NUMBER or ORG/REPO#NUMBER", ref)
+	}
+	number, err = strconv.Atoi(m[3])
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid issue %q: %w", ref, err)
+	}
+	if m[1] != "" {
+		project = m[1] + "/" + m[2]
+	}
+	return project, number, nil
+}
+
+// GetIssueRef returns the issue given as a reference, see ParseIssueRef.
+// References without a repo are looked up in the configured project.
+func GetIssueRef(ref string, opts ...Option) (*github.Issue, error) {
+	project, number, err := ParseIssueRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	if project != "" {
+		opts = append(opts[:len(opts):len(opts)], WithProject(project))
+	}
+	return GetIssue(number, opts...)
+}
+
+// ListOrgProjects returns the ORG/REPO projects of the org whose repo name
+// matches the glob, e.g. operator-*. Archived repos are skipped.
+func ListOrgProjects(org, glob string, opts ...Option) ([]string, error) {
+	config := ListerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.setDefaults(); err != nil {
+		return nil, err
+	}
+
+	client, err := config.newClient()
+	if err != nil {
+		return nil, err
+	}
+
+	if glob == "" {
+		glob = "*"
+	}
+	if _, err := path.Match(glob, ""); err != nil {
+		return nil, fmt.Errorf("invalid repo glob %q: %w", glob, err)
+	}
+
+	opt := &github.RepositoryListByOrgOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	var projects []string
+
+	for {
+		repos, resp, err := client.Repositories.ListByOrg(context.Background(), org, opt)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range repos {
+			if r.GetArchived() {
+				continue
+			}
+			if ok, _ := path.Match(glob, r.GetName()); ok {
+				projects = append(projects, org+"/"+r.GetName())
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	sort.Strings(projects)
+	return projects, nil
+}
+
+// ListProjectsIssues lists the issues of several projects concurrently, with
+// the same options for each, and merges them. The issues are sorted by the
+// configured sort or, by default, by project and then newest first.
+func ListProjectsIssues(projects []string, opts ...Option) ([]*github.Issue, error) {
+	config := ListerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([][]*github.Issue, len(projects))
+	errs := make([]error, len(projects))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrency)
+	for i, p := range projects {
+		wg.Add(1)
+		go func(i int, p string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i], errs[i] = ListIssues(append(opts[:len(opts):len(opts)], WithProject(p))...)
+		}(i, p)
+	}
+	wg.Wait()
+
+	var all []*github.Issue
+	for i, p := range projects {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("unable to list issues of %s: %w", p, errs[i])
+		}
+		all = append(all, results[i]...)
+	}
+
+	sortIssues(all, config.Sort, config.Direction)
+	return all, nil
+}
+
+// sortIssues sorts issues of several repos the way Github sorts the issues of
+// one: by created, updated or comments, descending by default. Without a sort
+// issues are grouped by repo.
+func sortIssues(issues []*github.Issue, by, direction string) {
+	repoOf := func(i *github.Issue) string {
+		org, repo := IssueRepo(i)
+		return org + "/" + repo
+	}
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if direction == "asc" {
+			a, b = b, a
+		}
+		switch by {
+		case "created":
+			if !a.GetCreatedAt().Equal(b.GetCreatedAt()) {
+				return a.GetCreatedAt().After(b.GetCreatedAt())
+			}
+		case "updated":
+			if !a.GetUpdatedAt().Equal(b.GetUpdatedAt()) {
+				return a.GetUpdatedAt().After(b.GetUpdatedAt())
+			}
+		case "comments":
+			if a.GetComments() != b.GetComments() {
+				return a.GetComments() > b.GetComments()
+			}
+		}
+		// ties and the default keep repos together, newest first
+		a, b = issues[i], issues[j]
+		if ra, rb := repoOf(a), repoOf(b); ra != rb {
+			return ra < rb
+		}
+		if direction == "asc" {
+			return a.GetNumber() < b.GetNumber()
+		}
+		return a.GetNumber() > b.GetNumber()
+	})
+}
@@ -0,0 +1,163 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gh
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/go-github/v47/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func repoIssue(project string, number int) *github.Issue {
+	return &github.Issue{
+		Number:        github.Int(number),
+		RepositoryURL: github.String("https://api.github.com/repos/" + project),
+	}
+}
+
+func refs(issues []*github.Issue) []string {
+	var r []string
+	for _, i := range issues {
+		org, repo := IssueRepo(i)
+		r = append(r, fmt.Sprintf("%s/%s#%d", org, repo, i.GetNumber()))
+	}
+	return r
+}
+
+var _ = Describe("Multi", func() {
+	Describe("ParseIssueRef", func() {
+		It("should parse plain numbers", func() {
+			for _, ref := range []string{"123", "#123"} {
+				project, number, err := ParseIssueRef(ref)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(project).To(BeEmpty())
+				Expect(number).To(Equal(123))
+			}
+		})
+		It("should parse references to other repos", func() {
+			project, number, err := ParseIssueRef("operator-framework/operator-lib#42")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(project).To(Equal("operator-framework/operator-lib"))
+			Expect(number).To(Equal(42))
+		})
+		It("should parse issue urls", func() {
+			project, number, err := ParseIssueRef("https://github.com/foo/bar/issues/7")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(project).To(Equal("foo/bar"))
+			Expect(number).To(Equal(7))
+		})
+		It("should reject anything else", func() {
+			for _, ref := range []string{"", "foo", "foo/bar", "foo#1", "#"} {
+				_, _, err := ParseIssueRef(ref)
+				Expect(err).To(HaveOccurred(), ref)
+			}
+		})
+	})
+
+	Describe("GetIssueRef", func() {
+		It("should get the issue from the referenced repo", func() {
+			var path string
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(
+					mock.GetReposIssuesByOwnerByRepoByIssueNumber,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						path = r.URL.Path
+						w.Write(mock.MustMarshal(github.Issue{Number: github.Int(42)}))
+					}),
+				),
+			)
+			issue, err := GetIssueRef("foo/lib#42", WithClient(mockedHTTPClient), WithProject("foo/bar"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(issue.GetNumber()).To(Equal(42))
+			Expect(path).To(Equal("/repos/foo/lib/issues/42"))
+		})
+	})
+
+	Describe("ListOrgProjects", func() {
+		It("should return the repos matching the glob", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetOrgsReposByOrg,
+					[]github.Repository{
+						{Name: github.String("operator-sdk")},
+						{Name: github.String("operator-lib")},
+						{Name: github.String("operator-old"), Archived: github.Bool(true)},
+						{Name: github.String("community-operators")},
+					},
+				),
+			)
+			projects, err := ListOrgProjects("operator-framework", "operator-*",
+				WithClient(mockedHTTPClient))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(projects).To(Equal([]string{
+				"operator-framework/operator-lib",
+				"operator-framework/operator-sdk",
+			}))
+		})
+		It("should reject bad globs", func() {
+			_, err := ListOrgProjects("foo", "[", WithClient(mock.NewMockedHTTPClient()))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("ListProjectsIssues", func() {
+		It("should merge the issues of all projects", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(
+					mock.GetReposIssuesByOwnerByRepo,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						project := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/repos/"), "/issues")
+						w.Write(mock.MustMarshal([]*github.Issue{
+							repoIssue(project, 1), repoIssue(project, 2),
+						}))
+					}),
+				),
+			)
+			issues, err := ListProjectsIssues([]string{"foo/b", "foo/a"},
+				WithClient(mockedHTTPClient))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(refs(issues)).To(Equal([]string{"foo/a#2", "foo/a#1", "foo/b#2", "foo/b#1"}))
+		})
+		It("should name the project that failed", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(
+					mock.GetReposIssuesByOwnerByRepo,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						mock.WriteError(w, http.StatusNotFound, "Not Found")
+					}),
+				),
+			)
+			_, err := ListProjectsIssues([]string{"foo/a"}, WithClient(mockedHTTPClient))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(HavePrefix("unable to list issues of foo/a"))
+		})
+	})
+
+	Describe("sortIssues", func() {
+		It("should sort by comments across repos", func() {
+			a, b, c := repoIssue("foo/a", 1), repoIssue("foo/b", 1), repoIssue("foo/c", 1)
+			a.Comments, b.Comments, c.Comments = github.Int(1), github.Int(5), github.Int(3)
+			issues := []*github.Issue{a, b, c}
+			sortIssues(issues, "comments", "")
+			Expect(refs(issues)).To(Equal([]string{"foo/b#1", "foo/c#1", "foo/a#1"}))
+			sortIssues(issues, "comments", "asc")
+			Expect(refs(issues)).To(Equal([]string{"foo/a#1", "foo/c#1", "foo/b#1"}))
+		})
+	})
+})
@@ -16,8 +16,16 @@ package gh
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/google/go-github/v47/github"
+
+	"github.com/jmrodri/gh2jira/internal/markdown"
+	"github.com/jmrodri/gh2jira/internal/redact"
 )
 
 // So we will want to allow this to be able to take in a specific GH issue id or
@@ -33,35 +41,306 @@ import (
 // gh2jira list --project operator-framework/operator-sdk [--milestone=] [--assignee=]
 // gh2jira copy GH# [--dry-run]
 
-func PrintGithubIssue(issue *github.Issue, oneline bool, color bool) {
+// Color modes of NewPrinter.
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
 
-	// fmt.Printf("%5d %s %+v\n", issue.GetNumber(), issue.GetTitle(), issue.GetMilestone())
-	// return
+// ColorModes are the supported color modes.
+var ColorModes = []string{ColorAuto, ColorAlways, ColorNever}
 
-	if oneline {
-		if color {
-			// print the id in yellow, then reset the rest of the line
-			fmt.Printf("\033[33m%5d\033[0m \033[32m%s\033[31m %s\033[0m %s\n", issue.GetNumber(), issue.GetState(), *issue.GetAssignee().Login, issue.GetTitle())
+const (
+	yellow = "\033[33m"
+	green  = "\033[32m"
+	red    = "\033[31m"
+	bold   = "\033[1m"
+	reset  = "\033[0m"
+)
+
+// Printer prints issues for people, as opposed to WriteIssues which writes
+// them for other tools.
+type Printer struct {
+	w     io.Writer
+	color bool
+	// ShowRepo prefixes every oneline issue with its ORG/REPO.
+	ShowRepo bool
+}
+
+// NewPrinter returns a printer writing to w. In the auto color mode output is
+// colored when w is a terminal and the NO_COLOR environment variable is not
+// set, see https://no-color.org.
+func NewPrinter(w io.Writer, colorMode string) (*Printer, error) {
+	if err := oneOf("color", colorMode, ColorModes...); err != nil {
+		return nil, err
+	}
+	p := &Printer{w: redact.Writer(w)}
+	switch colorMode {
+	case ColorAlways:
+		p.color = true
+	case ColorNever:
+		p.color = false
+	default:
+		p.color = os.Getenv("NO_COLOR") == "" && IsTerminal(w)
+	}
+	return p, nil
+}
+
+// IsTerminal tells whether w is a terminal.
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// Color tells whether the printer uses colors.
+func (p *Printer) Color() bool {
+	return p.color
+}
+
+// paint wraps s in the color escape code if colors are on.
+func (p *Printer) paint(color, s string) string {
+	if !p.color {
+		return s
+	}
+	return color + s + reset
+}
+
+// PrintIssues prints the issues one per line, or in the long format.
+func (p *Printer) PrintIssues(issues []*github.Issue, long bool) error {
+	for _, issue := range issues {
+		var err error
+		if long {
+			err = p.PrintLong(issue)
 		} else {
-			fmt.Printf("%5d %s %s %s\n", issue.GetNumber(), issue.GetState(), *issue.GetAssignee().Login, issue.GetTitle())
+			err = p.PrintOneline(issue)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PrintOneline prints the number, state, assignee and title of the issue on
+// one line.
+func (p *Printer) PrintOneline(issue *github.Issue) error {
+	var sb strings.Builder
+	if p.ShowRepo {
+		org, repo := IssueRepo(issue)
+		fmt.Fprintf(&sb, "%-40s ", org+"/"+repo)
+	}
+	sb.WriteString(p.paint(yellow, fmt.Sprintf("%5d", issue.GetNumber())))
+	sb.WriteString(" ")
+	if login := assigneeLogin(issue); login != "" {
+		if p.color {
+			// the state runs into the red assignee
+			fmt.Fprintf(&sb, "%s%s%s %s%s", green, issue.GetState(), red, login, reset)
+		} else {
+			fmt.Fprintf(&sb, "%s %s", issue.GetState(), login)
 		}
 	} else {
-		// fmt.Println(*issue.ID)
-		fmt.Printf("Issue:\t%d\n", issue.GetNumber())
-		// fmt.Println(*issue.Title)
-		fmt.Printf("State:\t%s\n", issue.GetState())
-		if issue.GetAssignee() != nil {
-			fmt.Printf("Assignee:\t%s\n", *issue.GetAssignee().Login)
+		sb.WriteString(p.paint(green, issue.GetState()))
+	}
+	fmt.Fprintf(&sb, " %s\n", issue.GetTitle())
+	_, err := io.WriteString(p.w, sb.String())
+	return err
+}
+
+// PrintLong prints the issue over several lines.
+func (p *Printer) PrintLong(issue *github.Issue) error {
+	var sb strings.Builder
+	if p.ShowRepo {
+		org, repo := IssueRepo(issue)
+		fmt.Fprintf(&sb, "Repo:\t%s/%s\n", org, repo)
+	}
+	fmt.Fprintf(&sb, "Issue:\t%d\n", issue.GetNumber())
+	fmt.Fprintf(&sb, "State:\t%s\n", issue.GetState())
+	if login := assigneeLogin(issue); login != "" {
+		fmt.Fprintf(&sb, "Assignee:\t%s\n", login)
+	}
+	if m := issue.GetMilestone().GetTitle(); m != "" {
+		fmt.Fprintf(&sb, "Milestone:\t%s\n", m)
+	}
+	if len(issue.Labels) > 0 {
+		var labels []string
+		for _, l := range issue.Labels {
+			labels = append(labels, l.GetName())
 		}
+		fmt.Fprintf(&sb, "Labels:\t%s\n", strings.Join(labels, ", "))
+	}
+	if u := issue.GetHTMLURL(); u != "" {
+		fmt.Fprintf(&sb, "URL:\t%s\n", u)
+	}
+	fmt.Fprintf(&sb, "\n   %s\n\n", issue.GetTitle())
+	_, err := io.WriteString(p.w, sb.String())
+	return err
+}
 
-		// NOTE: This should be the jira body
-		// fmt.Printf("Title:\t%s\n", issue.GetTitle())
-		fmt.Printf("\n   %s\n\n", issue.GetTitle())
-		// fmt.Printf("Body:\n\t%s\n", issue.GetBody())
+// IssueDetail is everything the detail view shows about an issue.
+type IssueDetail struct {
+	Issue        *github.Issue
+	Comments     []*github.IssueComment
+	PullRequests []*github.Issue
+	// JiraKey and JiraStatus of the clone, if the issue was cloned
+	JiraKey    string
+	JiraStatus string
+}
+
+// PrintDetail prints the full issue: its fields, the body and comments
+// rendered from Markdown, and the linked pull requests.
+func (p *Printer) PrintDetail(d *IssueDetail) error {
+	issue := d.Issue
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "%s %s\n", p.paint(yellow, fmt.Sprintf("#%d", issue.GetNumber())),
+		p.paint(bold, issue.GetTitle()))
+	fmt.Fprintf(&sb, "%s, opened by @%s on %s\n\n", p.paint(green, issue.GetState()),
+		issue.GetUser().GetLogin(), formatDate(issue.GetCreatedAt()))
 
-		// Look through the labels
-		// important soon should be Urgent
-		// important long term should be Medium
-		// fmt.Println(issue.Labels)
+	tw := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(tw, "%s:\t%s\n", name, value)
+		}
+	}
+	if org, repo := IssueRepo(issue); org != "" {
+		field("Repo", org+"/"+repo)
+	}
+	var assignees []string
+	for _, a := range Summarize(issue).Assignees {
+		assignees = append(assignees, "@"+a)
+	}
+	field("Assignees", strings.Join(assignees, ", "))
+	field("Labels", strings.Join(Summarize(issue).Labels, ", "))
+	field("Milestone", issue.GetMilestone().GetTitle())
+	field("Created", formatDate(issue.GetCreatedAt()))
+	field("Updated", formatDate(issue.GetUpdatedAt()))
+	if issue.ClosedAt != nil {
+		field("Closed", formatDate(issue.GetClosedAt()))
+	}
+	if d.JiraKey != "" {
+		jira := d.JiraKey
+		if d.JiraStatus != "" {
+			jira += " (" + d.JiraStatus + ")"
+		}
+		field("Jira", jira)
+	}
+	field("URL", issue.GetHTMLURL())
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(d.PullRequests) > 0 {
+		sb.WriteString("\nLinked pull requests:\n")
+		for _, pr := range d.PullRequests {
+			fmt.Fprintf(&sb, "  %s %s %s\n", p.paint(yellow, pr.GetHTMLURL()), p.paint(green, pr.GetState()),
+				pr.GetTitle())
+		}
+	}
+
+	if body := strings.TrimSpace(issue.GetBody()); body != "" {
+		sb.WriteString("\n")
+		sb.WriteString(markdown.Terminal(body, p.color))
+	}
+
+	if len(d.Comments) > 0 {
+		fmt.Fprintf(&sb, "\n%s\n", p.paint(bold, fmt.Sprintf("Comments (%d)", len(d.Comments))))
+		for _, c := range d.Comments {
+			fmt.Fprintf(&sb, "\n%s on %s:\n", p.paint(bold, "@"+c.GetUser().GetLogin()),
+				formatDate(c.GetCreatedAt()))
+			for _, l := range strings.Split(strings.TrimRight(markdown.Terminal(c.GetBody(), p.color), "\n"), "\n") {
+				sb.WriteString(strings.TrimRight("  "+l, " ") + "\n")
+			}
+		}
+	}
+
+	_, err := io.WriteString(p.w, sb.String())
+	return err
+}
+
+// States of a Check.
+const (
+	CheckPass = "pass"
+	CheckWarn = "warn"
+	CheckFail = "fail"
+)
+
+// Check is an item of a checklist, e.g. whether a token works.
+type Check struct {
+	Name   string
+	State  string
+	Detail string
+}
+
+// PrintChecks prints the checklist under the heading. It returns false if a
+// check failed.
+func (p *Printer) PrintChecks(heading string, checks []Check) (bool, error) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s\n", p.paint(bold, heading))
+
+	ok := true
+	tw := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+	for _, c := range checks {
+		color := green
+		switch c.State {
+		case CheckWarn:
+			color = yellow
+		case CheckFail:
+			color = red
+			ok = false
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\n", p.paint(color, "["+strings.ToUpper(c.State)+"]"), c.Name, c.Detail)
+	}
+	if err := tw.Flush(); err != nil {
+		return false, err
+	}
+
+	_, err := io.WriteString(p.w, sb.String())
+	return ok, err
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02 15:04 MST")
+}
+
+// assigneeLogin returns the login of the assignee, or of the first of several
+// assignees, or nothing if the issue is not assigned.
+func assigneeLogin(issue *github.Issue) string {
+	if login := issue.GetAssignee().GetLogin(); login != "" {
+		return login
+	}
+	for _, a := range issue.Assignees {
+		if a.GetLogin() != "" {
+			return a.GetLogin()
+		}
+	}
+	return ""
+}
+
+// PrintGithubIssue prints the issue to stdout, on one line or in the long
+// format. Colors only apply to the oneline format.
+//
+// Deprecated: use a Printer.
+func PrintGithubIssue(issue *github.Issue, oneline bool, color bool) {
+	mode := ColorNever
+	if color {
+		mode = ColorAlways
+	}
+	p, _ := NewPrinter(os.Stdout, mode)
+	if oneline {
+		_ = p.PrintOneline(issue)
+	} else {
+		_ = p.PrintLong(issue)
 	}
 }
@@ -15,12 +15,16 @@
 package gh
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	"github.com/google/go-github/v47/github"
 
+	"github.com/jmrodri/gh2jira/internal/redact"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -102,4 +106,142 @@ var _ = Describe("Printer", func() {
 			Expect(expectedLong).To(Equal(string(stdout)))
 		})
 	})
+
+	Describe("NewPrinter", func() {
+		var issue *github.Issue
+		BeforeEach(func() {
+			issue = &github.Issue{
+				Number:    github.Int(123),
+				Title:     github.String("Issue 1"),
+				State:     github.String("open"),
+				Assignees: []*github.User{{Login: github.String("jmrodri")}},
+			}
+		})
+		It("should reject unknown color modes", func() {
+			_, err := NewPrinter(io.Discard, "sometimes")
+			Expect(err).To(HaveOccurred())
+		})
+		It("should not color output that is not a terminal", func() {
+			var out bytes.Buffer
+			p, err := NewPrinter(&out, ColorAuto)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(p.Color()).To(BeFalse())
+			Expect(p.PrintOneline(issue)).To(Succeed())
+			Expect(out.String()).To(Equal("  123 open jmrodri Issue 1\n"))
+		})
+		It("should honor NO_COLOR", func() {
+			os.Setenv("NO_COLOR", "1")
+			defer os.Unsetenv("NO_COLOR")
+			p, err := NewPrinter(os.Stdout, ColorAuto)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(p.Color()).To(BeFalse())
+		})
+		It("should always color if asked to", func() {
+			var out bytes.Buffer
+			p, err := NewPrinter(&out, ColorAlways)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(p.PrintOneline(issue)).To(Succeed())
+			Expect(out.String()).To(Equal("\033[33m  123\033[0m \033[32mopen\033[31m jmrodri\033[0m Issue 1\n"))
+		})
+		It("should print the repo and the long format", func() {
+			var out bytes.Buffer
+			p, err := NewPrinter(&out, ColorNever)
+			Expect(err).NotTo(HaveOccurred())
+			p.ShowRepo = true
+			issue.RepositoryURL = github.String("https://api.github.com/repos/foo/bar")
+			issue.Labels = []*github.Label{{Name: github.String("kind/bug")}}
+			Expect(p.PrintIssues([]*github.Issue{issue}, true)).To(Succeed())
+			Expect(out.String()).To(Equal("Repo:\tfoo/bar\nIssue:\t123\nState:\topen\n" +
+				"Assignee:\tjmrodri\nLabels:\tkind/bug\n\n   Issue 1\n\n"))
+		})
+	})
+
+	Describe("PrintChecks", func() {
+		It("should print the checklist and tell whether all passed", func() {
+			var out bytes.Buffer
+			p, err := NewPrinter(&out, ColorNever)
+			Expect(err).NotTo(HaveOccurred())
+			ok, err := p.PrintChecks("Github https://github.com", []Check{
+				{Name: "token", State: CheckPass, Detail: "authenticated as janedoe"},
+				{Name: "scopes", State: CheckWarn, Detail: "not reported"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(out.String()).To(Equal("Github https://github.com\n" +
+				"  [PASS]  token   authenticated as janedoe\n" +
+				"  [WARN]  scopes  not reported\n"))
+
+			ok, err = p.PrintChecks("Jira", []Check{{Name: "token", State: CheckFail, Detail: "401"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeFalse())
+		})
+		It("should not print the tokens", func() {
+			redact.Add("ghp_secrettoken123")
+			defer redact.Reset()
+			var out bytes.Buffer
+			p, err := NewPrinter(&out, ColorNever)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = p.PrintChecks("Github", []Check{
+				{Name: "token", State: CheckFail, Detail: "bad credentials for ghp_secrettoken123"},
+				{Name: "request", State: CheckFail, Detail: "Authorization: Bearer unknown-token"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out.String()).NotTo(ContainSubstring("ghp_secrettoken123"))
+			Expect(out.String()).NotTo(ContainSubstring("unknown-token"))
+			Expect(out.String()).To(ContainSubstring("bad credentials for [REDACTED]"))
+		})
+	})
+
+	Describe("PrintDetail", func() {
+		It("should print the fields, body, linked prs and comments", func() {
+			created := time.Date(2022, 9, 1, 10, 0, 0, 0, time.UTC)
+			var out bytes.Buffer
+			p, err := NewPrinter(&out, ColorNever)
+			Expect(err).NotTo(HaveOccurred())
+			err = p.PrintDetail(&IssueDetail{
+				Issue: &github.Issue{
+					Number:        github.Int(123),
+					Title:         github.String("Issue 1"),
+					State:         github.String("open"),
+					Body:          github.String("Steps:\n\n- run **make**"),
+					User:          &github.User{Login: github.String("johndoe")},
+					RepositoryURL: github.String("https://api.github.com/repos/foo/bar"),
+					Labels:        []*github.Label{{Name: github.String("kind/bug")}},
+					CreatedAt:     &created,
+				},
+				Comments: []*github.IssueComment{{
+					Body:      github.String("me *too*"),
+					User:      &github.User{Login: github.String("jmrodri")},
+					CreatedAt: &created,
+				}},
+				PullRequests: []*github.Issue{{
+					Title:   github.String("Fix issue 1"),
+					State:   github.String("open"),
+					HTMLURL: github.String("https://github.com/foo/bar/pull/124"),
+				}},
+				JiraKey:    "OSDK-1",
+				JiraStatus: "In Progress",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(out.String()).To(Equal(`#123 Issue 1
+open, opened by @johndoe on 2022-09-01 10:00 UTC
+
+Repo:     foo/bar
+Labels:   kind/bug
+Created:  2022-09-01 10:00 UTC
+Jira:     OSDK-1 (In Progress)
+
+Linked pull requests:
+  https://github.com/foo/bar/pull/124 open Fix issue 1
+
+Steps:
+• run make
+
+Comments (1)
+
+@jmrodri on 2022-09-01 10:00 UTC:
+  me too
+`))
+		})
+	})
 })
@@ -0,0 +1,144 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gh
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v47/github"
+)
+
+// RefKind describes how an issue body refers to another issue.
+type RefKind int
+
+const (
+	// RelatesTo is a plain mention such as "see #123".
+	RelatesTo RefKind = iota
+	// BlockedBy is a mention worded as a dependency such as
+	// "blocked by #123" or "depends on org/repo#123".
+	BlockedBy
+)
+
+// Reference is a mention of a Github issue found in an issue body.
+type Reference struct {
+	Org    string
+	Repo   string
+	Number int
+	Kind   RefKind
+	// Text is the reference exactly as it appears in the body, Start and End
+	// are its byte offsets.
+	Text  string
+	Start int
+	End   int
+}
+
+var (
+	// matches https://github.com/org/repo/issues/123, org/repo#123 and #123
+	refRegex = regexp.MustCompile(
+		`https?://[^\s/]+/([\w.-]+)/([\w.-]+)/issues/(\d+)|(?:([\w.-]+)/([\w.-]+))?#(\d+)`)
+	blockedRegex = regexp.MustCompile(
+		`(?i)\b(blocked by|blocked on|depends on|depending on|requires)\b`)
+)
+
+// FindReferences returns the Github issue references in body. Short references
+// like #123 are resolved against the given org and repo. References inside
+// fenced code blocks are ignored.
+func FindReferences(body, org, repo string) []Reference {
+	var refs []Reference
+
+	offset := 0
+	fenced := false
+	for _, line := range strings.SplitAfter(body, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			fenced = !fenced
+		}
+		if !fenced {
+			refs = append(refs, findLineReferences(line, offset, org, repo)...)
+		}
+		offset += len(line)
+	}
+	return refs
+}
+
+func findLineReferences(line string, offset int, org, repo string) []Reference {
+	var refs []Reference
+	for _, m := range refRegex.FindAllStringSubmatchIndex(line, -1) {
+		ref := Reference{
+			Org:   org,
+			Repo:  repo,
+			Text:  line[m[0]:m[1]],
+			Start: offset + m[0],
+			End:   offset + m[1],
+		}
+		var num string
+		if m[2] != -1 {
+			ref.Org, ref.Repo, num = line[m[2]:m[3]], line[m[4]:m[5]], line[m[6]:m[7]]
+		} else {
+			// avoid things like foo#1 or html entities like &#123;
+			if m[0] > 0 && m[8] == -1 && isWordByte(line[m[0]-1]) {
+				continue
+			}
+			if m[8] != -1 {
+				ref.Org, ref.Repo = line[m[8]:m[9]], line[m[10]:m[11]]
+			}
+			num = line[m[12]:m[13]]
+		}
+		ref.Number, _ = strconv.Atoi(num)
+		if ref.Number == 0 {
+			continue
+		}
+		if blockedRegex.MatchString(sentenceBefore(line[:m[0]])) {
+			ref.Kind = BlockedBy
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// sentenceBefore returns the part of the current sentence that precedes a
+// reference.
+func sentenceBefore(s string) string {
+	if i := strings.LastIndexAny(s, ".!?;"); i != -1 {
+		return s[i+1:]
+	}
+	return s
+}
+
+func isWordByte(b byte) bool {
+	return b == '&' || b == '_' || b == '-' ||
+		('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z') || ('0' <= b && b <= '9')
+}
+
+// IssueRepo returns the org and repo the given issue belongs to, derived from
+// its repository or issue URLs.
+func IssueRepo(issue *github.Issue) (string, string) {
+	if u := issue.GetRepositoryURL(); u != "" {
+		s := strings.Split(strings.TrimSuffix(u, "/"), "/")
+		if len(s) >= 2 {
+			return s[len(s)-2], s[len(s)-1]
+		}
+	}
+	for _, u := range []string{issue.GetURL(), issue.GetHTMLURL()} {
+		s := strings.Split(u, "/")
+		for i := len(s) - 1; i >= 2; i-- {
+			if s[i] == "issues" {
+				return s[i-2], s[i-1]
+			}
+		}
+	}
+	return "", ""
+}
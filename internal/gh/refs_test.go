@@ -0,0 +1,96 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gh
+
+import (
+	"github.com/google/go-github/v47/github"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Refs", func() {
+	Describe("FindReferences", func() {
+		It("should return nothing if there are no references", func() {
+			Expect(FindReferences("nothing to see here", "foo", "bar")).To(BeEmpty())
+		})
+		It("should resolve short references against the given repo", func() {
+			refs := FindReferences("see #2891 for details", "foo", "bar")
+			Expect(refs).To(HaveLen(1))
+			Expect(refs[0].Org).To(Equal("foo"))
+			Expect(refs[0].Repo).To(Equal("bar"))
+			Expect(refs[0].Number).To(Equal(2891))
+			Expect(refs[0].Kind).To(Equal(RelatesTo))
+			Expect(refs[0].Text).To(Equal("#2891"))
+		})
+		It("should find cross repo references", func() {
+			body := "blocked by operator-framework/operator-lifecycle-manager#100"
+			refs := FindReferences(body, "foo", "bar")
+			Expect(refs).To(HaveLen(1))
+			Expect(refs[0].Org).To(Equal("operator-framework"))
+			Expect(refs[0].Repo).To(Equal("operator-lifecycle-manager"))
+			Expect(refs[0].Number).To(Equal(100))
+			Expect(refs[0].Kind).To(Equal(BlockedBy))
+			Expect(body[refs[0].Start:refs[0].End]).To(Equal(refs[0].Text))
+		})
+		It("should find issue urls", func() {
+			refs := FindReferences("Depends on https://github.com/a/b/issues/7.", "foo", "bar")
+			Expect(refs).To(HaveLen(1))
+			Expect(refs[0].Org).To(Equal("a"))
+			Expect(refs[0].Repo).To(Equal("b"))
+			Expect(refs[0].Number).To(Equal(7))
+			Expect(refs[0].Kind).To(Equal(BlockedBy))
+		})
+		It("should only treat the current sentence as blocking", func() {
+			refs := FindReferences("This is blocked by #1 and #2. See also #3", "foo", "bar")
+			Expect(refs).To(HaveLen(3))
+			Expect(refs[0].Kind).To(Equal(BlockedBy))
+			Expect(refs[1].Kind).To(Equal(BlockedBy))
+			Expect(refs[2].Kind).To(Equal(RelatesTo))
+		})
+		It("should ignore things that only look like references", func() {
+			Expect(FindReferences("foo#1 &#123; # heading", "foo", "bar")).To(BeEmpty())
+		})
+		It("should ignore references in fenced code blocks", func() {
+			body := "see #1\n```\n#2\n```\nand #3\n"
+			refs := FindReferences(body, "foo", "bar")
+			Expect(refs).To(HaveLen(2))
+			Expect(refs[0].Number).To(Equal(1))
+			Expect(refs[1].Number).To(Equal(3))
+			Expect(body[refs[1].Start:refs[1].End]).To(Equal("#3"))
+		})
+	})
+	Describe("IssueRepo", func() {
+		It("should prefer the repository url", func() {
+			org, repo := IssueRepo(&github.Issue{
+				RepositoryURL: github.String("https://api.github.com/repos/foo/bar"),
+			})
+			Expect(org).To(Equal("foo"))
+			Expect(repo).To(Equal("bar"))
+		})
+		It("should fall back to the issue url", func() {
+			org, repo := IssueRepo(&github.Issue{
+				URL: github.String("https://api.github.com/repos/foo/bar/issues/123"),
+			})
+			Expect(org).To(Equal("foo"))
+			Expect(repo).To(Equal("bar"))
+		})
+		It("should return empty strings if it can't tell", func() {
+			org, repo := IssueRepo(nil)
+			Expect(org).To(Equal(""))
+			Expect(repo).To(Equal(""))
+		})
+	})
+})
@@ -0,0 +1,161 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gh
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v47/github"
+)
+
+var (
+	// scopeRegex finds qualifiers that already say where to search
+	scopeRegex = regexp.MustCompile(`(^|\s)-?(repo|org|user):\S`)
+	// typeRegex finds qualifiers that say whether to search issues or PRs
+	typeRegex = regexp.MustCompile(`(^|\s)-?(is|type):(issue|pr|pull-request)\b`)
+	// stateRegex finds qualifiers that select open or closed issues
+	stateRegex = regexp.MustCompile(`(^|\s)-?(is|state):(open|closed)\b`)
+)
+
+// maxSearchResults is how many results the search API returns at most.
+const maxSearchResults = 1000
+
+// searchQuery completes the Github search query: it is scoped to the
+// configured repos and to issues, and limited to the configured state, unless
+// the query says otherwise. The other filters become qualifiers, but for the
+// labels of WithAnyLabel which search cannot express.
+func (c *ListerConfig) searchQuery(query string) (string, error) {
+	q := []string{strings.TrimSpace(query)}
+	if !scopeRegex.MatchString(query) {
+		if len(c.Projects) == 0 {
+			q = append(q, fmt.Sprintf("repo:%s/%s", c.GetGithubOrg(), c.GetGithubRepo()))
+		}
+		for _, p := range c.Projects {
+			q = append(q, "repo:"+p)
+		}
+	}
+	if !typeRegex.MatchString(query) {
+		q = append(q, "is:issue")
+	}
+	if c.State != "" && c.State != "all" && !stateRegex.MatchString(query) {
+		q = append(q, "state:"+c.State)
+	}
+	if !c.AnyLabel {
+		for _, l := range c.Label {
+			q = append(q, fmt.Sprintf("label:%q", l))
+		}
+	}
+	for _, l := range c.ExcludeLabel {
+		q = append(q, fmt.Sprintf("-label:%q", l))
+	}
+	switch {
+	case c.Assignee == "" && c.NoAssignee:
+		q = append(q, "no:assignee")
+	case c.Assignee == "":
+	case c.Assignee == "none":
+		q = append(q, "no:assignee")
+	case c.Assignee == "*":
+		q = append(q, "assignee:*")
+	default:
+		q = append(q, "assignee:"+c.Assignee)
+	}
+	switch {
+	case c.Milestone == "" && c.NoMilestone:
+		q = append(q, "no:milestone")
+	case c.Milestone == "", c.Milestone == "*":
+	case c.Milestone == "none":
+		q = append(q, "no:milestone")
+	default:
+		if _, err := strconv.Atoi(c.Milestone); err == nil {
+			return "", fmt.Errorf("search queries need the milestone title, not its number %s", c.Milestone)
+		}
+		q = append(q, fmt.Sprintf("milestone:%q", c.Milestone))
+	}
+	if !c.Since.IsZero() {
+		q = append(q, "updated:>="+c.Since.UTC().Format(time.RFC3339))
+	}
+	return strings.Join(q, " "), nil
+}
+
+// SearchIssues returns the issues matching the Github issue search query, see
+// https://docs.github.com/en/search-github/searching-on-github/searching-issues-and-pull-requests
+//
+// The query is scoped to the configured projects unless it has a repo:, org:
+// or user: qualifier, and to open issues unless it has a state qualifier or
+// another state is set with WithState. The filters are part of the query but
+// for WithAnyLabel, applied to the results. Search stops at 1000 results, a
+// warning says when more issues matched.
+func SearchIssues(query string, opts ...Option) ([]*github.Issue, error) {
+	config := ListerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.setDefaults(); err != nil {
+		return nil, err
+	}
+
+	q, err := config.searchQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := config.validateFilter(); err != nil {
+		return nil, err
+	}
+
+	client, err := config.newClient()
+	if err != nil {
+		return nil, err
+	}
+
+	opt := &github.SearchOptions{
+		ListOptions: github.ListOptions{PerPage: 100},
+		Sort:        config.Sort,
+		Order:       config.Direction,
+	}
+
+	var allIssues []*github.Issue
+
+	for {
+		result, resp, err := client.Search.Issues(context.Background(), q, opt)
+		if err != nil {
+			return nil, fmt.Errorf("search %q failed: %w", q, err)
+		}
+
+		if opt.Page == 0 && result.GetTotal() > maxSearchResults {
+			fmt.Fprintf(config.warnings, "Warning: %d issues match %q, only the first %d are returned, "+
+				"narrow the query down\n", result.GetTotal(), q, maxSearchResults)
+		}
+		allIssues = append(allIssues, result.Issues...)
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	if !config.AnyLabel {
+		return allIssues, nil
+	}
+	anyLabel := &Filter{Labels: config.Label, AnyLabel: true}
+	return anyLabel.Apply(allIssues), nil
+}
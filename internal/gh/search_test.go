@@ -0,0 +1,162 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gh
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/go-github/v47/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Search", func() {
+	Describe("searchQuery", func() {
+		config := ListerConfig{Project: "foo/bar"}
+
+		It("should scope the query to the repo and to issues", func() {
+			Expect(config.searchQuery("author:johndoe comments:>10")).
+				To(Equal("author:johndoe comments:>10 repo:foo/bar is:issue"))
+		})
+		It("should scope the query to several repos", func() {
+			c := ListerConfig{Project: "foo/bar", Projects: []string{"foo/bar", "foo/baz"}}
+			Expect(c.searchQuery("label:bug")).To(Equal("label:bug repo:foo/bar repo:foo/baz is:issue"))
+			Expect(c.searchQuery("org:foo label:bug")).To(Equal("org:foo label:bug is:issue"))
+		})
+		It("should keep the scope of the query", func() {
+			Expect(config.searchQuery("org:operator-framework is:pr")).
+				To(Equal("org:operator-framework is:pr"))
+		})
+		It("should add the state unless the query has one", func() {
+			c := ListerConfig{Project: "foo/bar", State: "open"}
+			Expect(c.searchQuery("reactions:>5")).To(Equal("reactions:>5 repo:foo/bar is:issue state:open"))
+			Expect(c.searchQuery("is:closed")).To(Equal("is:closed repo:foo/bar is:issue"))
+			c.State = "all"
+			Expect(c.searchQuery("reactions:>5")).To(Equal("reactions:>5 repo:foo/bar is:issue"))
+		})
+		It("should turn the other filters into qualifiers", func() {
+			c := ListerConfig{
+				Project:   "foo/bar",
+				Assignee:  "none",
+				Milestone: "v1.27.0",
+				Since:     time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC),
+			}
+			Expect(c.searchQuery("mentions:johndoe")).To(Equal("mentions:johndoe repo:foo/bar is:issue " +
+				`no:assignee milestone:"v1.27.0" updated:>=2022-09-01T00:00:00Z`))
+		})
+		It("should turn the label filters into qualifiers", func() {
+			c := ListerConfig{
+				Project:      "foo/bar",
+				Label:        []string{"kind/bug", "good first issue"},
+				ExcludeLabel: []string{"lifecycle/stale"},
+				NoAssignee:   true,
+				NoMilestone:  true,
+			}
+			Expect(c.searchQuery("author:johndoe")).To(Equal("author:johndoe repo:foo/bar is:issue " +
+				`label:"kind/bug" label:"good first issue" -label:"lifecycle/stale" no:assignee no:milestone`))
+			c.AnyLabel = true
+			Expect(c.searchQuery("author:johndoe")).To(Equal("author:johndoe repo:foo/bar is:issue " +
+				`-label:"lifecycle/stale" no:assignee no:milestone`))
+		})
+		It("should reject milestone numbers", func() {
+			c := ListerConfig{Project: "foo/bar", Milestone: "12"}
+			_, err := c.searchQuery("mentions:johndoe")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("SearchIssues", func() {
+		It("should return an error if there is no token", func() {
+			iss, err := SearchIssues("author:johndoe")
+			Expect(iss).To(BeNil())
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("cannot create github client without a token"))
+		})
+		It("should page through all the results", func() {
+			var queries []string
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(
+					mock.GetSearchIssues,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						queries = append(queries, r.URL.Query().Get("q"))
+						page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+						if page < 2 {
+							w.Header().Set("Link",
+								fmt.Sprintf(`<https://api.github.com/search/issues?page=%d>; rel="next"`, page+1))
+						}
+						w.Write(mock.MustMarshal(github.IssuesSearchResult{
+							Total:  github.Int(2),
+							Issues: []*github.Issue{labeled(page, "kind/bug")},
+						}))
+					}),
+				),
+			)
+			iss, err := SearchIssues("comments:>10", WithClient(mockedHTTPClient),
+				WithProject("foo/bar"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(numbers(iss)).To(Equal([]int{0, 1, 2}))
+			Expect(queries).To(HaveLen(3))
+			Expect(queries[0]).To(Equal("comments:>10 repo:foo/bar is:issue state:open"))
+		})
+		It("should match any label on the results", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetSearchIssues,
+					github.IssuesSearchResult{
+						Issues: []*github.Issue{labeled(1, "kind/bug"), labeled(2, "lifecycle/stale"),
+							labeled(3, "kind/feature")},
+					},
+				),
+			)
+			iss, err := SearchIssues("author:johndoe", WithClient(mockedHTTPClient),
+				WithProject("foo/bar"), WithLabel([]string{"kind/bug", "kind/feature"}), WithAnyLabel(true))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(numbers(iss)).To(Equal([]int{1, 3}))
+		})
+		It("should warn that results past 1000 are left out", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetSearchIssues,
+					github.IssuesSearchResult{
+						Total:  github.Int(1234),
+						Issues: []*github.Issue{labeled(1, "kind/bug")},
+					},
+				),
+			)
+			warnings := &bytes.Buffer{}
+			_, err := SearchIssues("author:johndoe", WithClient(mockedHTTPClient),
+				WithProject("foo/bar"), WithWarnings(warnings))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings.String()).To(HavePrefix("Warning: 1234 issues match"))
+			Expect(warnings.String()).To(ContainSubstring("only the first 1000 are returned"))
+		})
+		It("should return error if the search fails", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(
+					mock.GetSearchIssues,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						mock.WriteError(w, http.StatusUnprocessableEntity, "Validation Failed")
+					}),
+				),
+			)
+			iss, err := SearchIssues("bad:", WithClient(mockedHTTPClient), WithProject("foo/bar"))
+			Expect(iss).To(BeNil())
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,75 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gh
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/go-github/v47/github"
+)
+
+// TokenInfo describes what a Github token gives access to.
+type TokenInfo struct {
+	User *github.User
+	// Scopes are the OAuth scopes of a classic token. They are nil if Github
+	// does not report them, e.g. for fine-grained tokens.
+	Scopes []string
+	Rate   github.Rate
+}
+
+// GetTokenInfo returns the user, scopes and rate limit of the token.
+func GetTokenInfo(opts ...Option) (*TokenInfo, error) {
+	config := ListerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.setDefaults(); err != nil {
+		return nil, err
+	}
+
+	client, err := config.newClient()
+	if err != nil {
+		return nil, err
+	}
+
+	user, resp, err := client.Users.Get(context.Background(), "")
+	if err != nil {
+		return nil, err
+	}
+	info := &TokenInfo{User: user, Rate: resp.Rate}
+	if values, ok := resp.Header["X-Oauth-Scopes"]; ok {
+		info.Scopes = []string{}
+		for _, s := range strings.Split(strings.Join(values, ","), ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				info.Scopes = append(info.Scopes, s)
+			}
+		}
+	}
+	return info, nil
+}
+
+// CurrentUser returns the Github user the token belongs to. It tells whether
+// the token works.
+func CurrentUser(opts ...Option) (*github.User, error) {
+	info, err := GetTokenInfo(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return info.User, nil
+}
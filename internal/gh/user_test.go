@@ -0,0 +1,98 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gh
+
+import (
+	"net/http"
+
+	"github.com/google/go-github/v47/github"
+	"github.com/migueleliasweb/go-github-mock/src/mock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("User", func() {
+	Describe("CurrentUser", func() {
+		It("should return an error if there is no token", func() {
+			_, err := CurrentUser()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("cannot create github client without a token"))
+		})
+		It("should return the user of the token", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetUser,
+					github.User{Login: github.String("janedoe")},
+				),
+			)
+			user, err := CurrentUser(WithClient(mockedHTTPClient))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(user.GetLogin()).To(Equal("janedoe"))
+		})
+		It("should return an error if the token is rejected", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(mock.GetUser,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						w.WriteHeader(http.StatusUnauthorized)
+						w.Write([]byte(`{"message":"Bad credentials"}`))
+					}),
+				),
+			)
+			_, err := CurrentUser(WithClient(mockedHTTPClient))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Bad credentials"))
+		})
+	})
+	Describe("GetTokenInfo", func() {
+		It("should return the scopes and rate limit", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(mock.GetUser,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						w.Header().Set("X-OAuth-Scopes", "public_repo, read:project")
+						w.Header().Set("X-RateLimit-Limit", "5000")
+						w.Header().Set("X-RateLimit-Remaining", "4990")
+						w.Write(mock.MustMarshal(github.User{Login: github.String("janedoe")}))
+					}),
+				),
+			)
+			info, err := GetTokenInfo(WithClient(mockedHTTPClient))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.User.GetLogin()).To(Equal("janedoe"))
+			Expect(info.Scopes).To(Equal([]string{"public_repo", "read:project"}))
+			Expect(info.Rate.Limit).To(Equal(5000))
+			Expect(info.Rate.Remaining).To(Equal(4990))
+		})
+		It("should tell a token without scopes from unreported scopes", func() {
+			mockedHTTPClient := mock.NewMockedHTTPClient(
+				mock.WithRequestMatchHandler(mock.GetUser,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						w.Header().Set("X-OAuth-Scopes", "")
+						w.Write(mock.MustMarshal(github.User{Login: github.String("janedoe")}))
+					}),
+				),
+			)
+			info, err := GetTokenInfo(WithClient(mockedHTTPClient))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Scopes).To(BeEmpty())
+			Expect(info.Scopes).NotTo(BeNil())
+
+			mockedHTTPClient = mock.NewMockedHTTPClient(
+				mock.WithRequestMatch(mock.GetUser, github.User{Login: github.String("janedoe")}),
+			)
+			info, err = GetTokenInfo(WithClient(mockedHTTPClient))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Scopes).To(BeNil())
+		})
+	})
+})
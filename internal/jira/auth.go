@@ -0,0 +1,121 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"net/url"
+	"strings"
+
+	gojira "github.com/andygrunwald/go-jira"
+
+	"github.com/jmrodri/gh2jira/internal/jiraauth"
+)
+
+const (
+	// AuthBearer sends the token as a bearer token, a personal access token
+	// of Jira Server and Data Center.
+	AuthBearer = jiraauth.Bearer
+	// AuthBasic sends the user and the token as basic auth, an API token of
+	// Jira Cloud.
+	AuthBasic = jiraauth.Basic
+)
+
+// AuthModes are the ways to authenticate to Jira.
+var AuthModes = jiraauth.Modes
+
+// RequiredPermissions are the project permissions cloning needs.
+var RequiredPermissions = []string{"CREATE_ISSUES", "LINK_ISSUES", "ADD_COMMENTS"}
+
+// WithAuth sets how the token is sent to Jira. The user, usually an email
+// address, is required for basic auth. Bearer is the default.
+func WithAuth(mode, user string) Option {
+	return func(c *ClonerConfig) error {
+		if err := jiraauth.Validate(mode, user); err != nil {
+			return err
+		}
+		c.authMode = mode
+		c.user = user
+		return nil
+	}
+}
+
+// Myself returns the Jira user the token belongs to. It tells whether the
+// token works.
+func Myself(opts ...Option) (*gojira.User, error) {
+	config := ClonerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.setDefaults(); err != nil {
+		return nil, err
+	}
+
+	jiraClient, err := gojira.NewClient(config.client, config.jiraURL)
+	if err != nil {
+		return nil, err
+	}
+
+	user, _, err := jiraClient.User.GetSelf()
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// MyPermissions tells which of the permissions, e.g. CREATE_ISSUES, the user
+// of the token has in the project. It fails if the project does not exist.
+func MyPermissions(project string, permissions []string, opts ...Option) (map[string]bool, error) {
+	config := ClonerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.setDefaults(); err != nil {
+		return nil, err
+	}
+
+	jiraClient, err := gojira.NewClient(config.client, config.jiraURL)
+	if err != nil {
+		return nil, err
+	}
+
+	q := url.Values{}
+	q.Set("projectKey", project)
+	q.Set("permissions", strings.Join(permissions, ","))
+	req, err := jiraClient.NewRequest("GET", "rest/api/2/mypermissions?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Permissions map[string]struct {
+			HavePermission bool `json:"havePermission"`
+		} `json:"permissions"`
+	}
+	resp, err := jiraClient.Do(req, &result)
+	if err != nil {
+		return nil, gojira.NewJiraError(resp, err)
+	}
+
+	have := map[string]bool{}
+	for _, p := range permissions {
+		have[p] = result.Permissions[p].HavePermission
+	}
+	return have, nil
+}
@@ -0,0 +1,127 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"net/http"
+	"net/url"
+
+	gojira "github.com/andygrunwald/go-jira"
+	jmock "github.com/jmrodri/gh2jira/internal/jira/mock"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Auth", func() {
+	Describe("WithAuth", func() {
+		It("should default to bearer auth", func() {
+			config := ClonerConfig{}
+			Expect(WithAuth("", "")(&config)).To(Succeed())
+			Expect(config.authMode).To(BeEmpty())
+		})
+		It("should require a user for basic auth", func() {
+			config := ClonerConfig{}
+			err := WithAuth(AuthBasic, "")(&config)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("basic auth requires a jira user"))
+		})
+		It("should reject unknown modes", func() {
+			config := ClonerConfig{}
+			err := WithAuth("oauth", "")(&config)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal(`invalid jira auth mode "oauth", must be one of bearer, basic`))
+		})
+		It("should send the user and token as basic auth", func() {
+			config := ClonerConfig{}
+			Expect(WithAuth(AuthBasic, "jane@example.com")(&config)).To(Succeed())
+			Expect(WithToken("secret")(&config)).To(Succeed())
+			Expect(config.setDefaults()).To(Succeed())
+
+			tp, ok := config.client.Transport.(*gojira.BasicAuthTransport)
+			Expect(ok).To(BeTrue())
+			Expect(tp.Username).To(Equal("jane@example.com"))
+			Expect(tp.Password).To(Equal("secret"))
+		})
+	})
+	Describe("Myself", func() {
+		It("should return an error if there is no token", func() {
+			_, err := Myself()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("cannot create jira client without a token"))
+		})
+		It("should return the user of the token", func() {
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetMyself,
+					gojira.User{Name: "jdoe", DisplayName: "Jane Doe"},
+				),
+			)
+			user, err := Myself(WithClient(mockedHTTPClient), WithJiraURL("http://localhost"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(user.DisplayName).To(Equal("Jane Doe"))
+		})
+		It("should return an error if the token is rejected", func() {
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatchHandler(jmock.GetMyself,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						jmock.WriteError(w, http.StatusUnauthorized, "unauthorized")
+					}),
+				),
+			)
+			_, err := Myself(WithClient(mockedHTTPClient), WithJiraURL("http://localhost"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	Describe("MyPermissions", func() {
+		It("should tell which permissions the user has", func() {
+			var query url.Values
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatchHandler(jmock.GetMyPermissions,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						query = r.URL.Query()
+						w.Write([]byte(`{"permissions": {
+							"CREATE_ISSUES": {"key": "CREATE_ISSUES", "havePermission": true},
+							"LINK_ISSUES": {"key": "LINK_ISSUES", "havePermission": false}
+						}}`))
+					}),
+				),
+			)
+			have, err := MyPermissions("OSDK", RequiredPermissions,
+				WithClient(mockedHTTPClient), WithJiraURL("http://localhost"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(query.Get("projectKey")).To(Equal("OSDK"))
+			Expect(query.Get("permissions")).To(Equal("CREATE_ISSUES,LINK_ISSUES,ADD_COMMENTS"))
+			Expect(have).To(Equal(map[string]bool{
+				"CREATE_ISSUES": true,
+				"LINK_ISSUES":   false,
+				"ADD_COMMENTS":  false,
+			}))
+		})
+		It("should return an error if the project does not exist", func() {
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatchHandler(jmock.GetMyPermissions,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						w.WriteHeader(http.StatusNotFound)
+						w.Write([]byte(`{"errorMessages":["No project could be found with key 'NOPE'."]}`))
+					}),
+				),
+			)
+			_, err := MyPermissions("NOPE", RequiredPermissions,
+				WithClient(mockedHTTPClient), WithJiraURL("http://localhost"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("No project could be found with key 'NOPE'."))
+		})
+	})
+})
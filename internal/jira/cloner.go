@@ -15,23 +15,46 @@
 package jira
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	gojira "github.com/andygrunwald/go-jira"
 	"github.com/google/go-github/v47/github"
+
+	"github.com/jmrodri/gh2jira/internal/adf"
+	"github.com/jmrodri/gh2jira/internal/gh"
+	"github.com/jmrodri/gh2jira/internal/mapping"
+	"github.com/jmrodri/gh2jira/internal/redact"
+	"github.com/jmrodri/gh2jira/internal/transport"
 )
 
 type Option func(*ClonerConfig) error
 
 type ClonerConfig struct {
-	client  *http.Client
-	token   string
-	dryRun  bool
-	project string
-	jiraURL string
+	client   *http.Client
+	token    string
+	authMode string
+	user     string
+	dryRun   bool
+	project  string
+	jiraURL  string
+	resolver IssueResolver
+	store    *mapping.Store
+
+	watchers       []string
+	watchAssignees bool
+	participants   []string
+	userMap        map[string]string
+
+	sensitive *SensitivePolicy
+	transport *transport.Config
+
+	apiVersion int
+	comments   []*github.IssueComment
 }
 
 func (c *ClonerConfig) setDefaults() error {
@@ -39,14 +62,33 @@ func (c *ClonerConfig) setDefaults() error {
 		if c.token == "" {
 			return errors.New("cannot create jira client without a token")
 		}
-		tp := gojira.BearerAuthTransport{
-			Token: c.token,
+		base, err := c.transport.Transport()
+		if err != nil {
+			return err
+		}
+		// errors quoting the request must not leak the token
+		t := redact.Transport(base)
+		if c.authMode == AuthBasic {
+			tp := gojira.BasicAuthTransport{
+				Username:  c.user,
+				Password:  c.token,
+				Transport: t,
+			}
+			c.client = tp.Client()
+		} else {
+			tp := gojira.BearerAuthTransport{
+				Token:     c.token,
+				Transport: t,
+			}
+			c.client = tp.Client()
 		}
-		c.client = tp.Client()
 	}
 	if c.jiraURL == "" {
 		c.jiraURL = "https://issues.redhat.com"
 	}
+	if c.apiVersion == 0 {
+		c.apiVersion = APIv2
+	}
 	return nil
 }
 
@@ -85,6 +127,97 @@ func WithJiraURL(j string) Option {
 	}
 }
 
+// WithTransport sets the TLS and proxy settings used to reach Jira. It has no
+// effect if a client is given with WithClient.
+func WithTransport(t *transport.Config) Option {
+	return func(c *ClonerConfig) error {
+		c.transport = t
+		return nil
+	}
+}
+
+// WithIssueResolver sets how referenced Github issues are matched to their
+// Jira counterparts. By default Jira is searched for the upstream issue URL.
+func WithIssueResolver(r IssueResolver) Option {
+	return func(c *ClonerConfig) error {
+		c.resolver = r
+		return nil
+	}
+}
+
+// WithMappingStore records every cloned issue in the given store. The store is
+// also consulted first when resolving referenced issues.
+func WithMappingStore(s *mapping.Store) Option {
+	return func(c *ClonerConfig) error {
+		c.store = s
+		return nil
+	}
+}
+
+// WithWatchers adds the given Jira users as watchers of every clone.
+func WithWatchers(users []string) Option {
+	return func(c *ClonerConfig) error {
+		c.watchers = users
+		return nil
+	}
+}
+
+// WithWatchAssignees adds the Jira users of the Github assignees as watchers.
+func WithWatchAssignees(w bool) Option {
+	return func(c *ClonerConfig) error {
+		c.watchAssignees = w
+		return nil
+	}
+}
+
+// WithParticipants adds the Jira users of the given Github logins, e.g. the
+// commenters of the issue, as watchers.
+func WithParticipants(logins []string) Option {
+	return func(c *ClonerConfig) error {
+		c.participants = logins
+		return nil
+	}
+}
+
+// WithUserMap maps Github logins to Jira usernames. Logins missing from the
+// map are assumed to have the same Jira username.
+func WithUserMap(m map[string]string) Option {
+	return func(c *ClonerConfig) error {
+		c.userMap = m
+		return nil
+	}
+}
+
+// WithSensitivePolicy sets how issues with sensitive labels, e.g. security
+// issues, are cloned. Without a policy all issues are cloned the same way.
+func WithSensitivePolicy(p *SensitivePolicy) Option {
+	return func(c *ClonerConfig) error {
+		c.sensitive = p
+		return nil
+	}
+}
+
+// WithAPIVersion sets the Jira REST API version used to create issues and
+// comments. Version 3, only available on Jira Cloud, takes descriptions and
+// comments in Atlassian Document Format. Defaults to 2.
+func WithAPIVersion(v int) Option {
+	return func(c *ClonerConfig) error {
+		if v != APIv2 && v != APIv3 {
+			return fmt.Errorf("unsupported jira api version %d, must be %d or %d", v, APIv2, APIv3)
+		}
+		c.apiVersion = v
+		return nil
+	}
+}
+
+// WithComments copies the given Github comments to the clone.
+func WithComments(comments []*github.IssueComment) Option {
+	return func(c *ClonerConfig) error {
+		c.comments = comments
+		return nil
+	}
+}
+
 func getWebURL(url string) string {
 	// https://api.github.com/repos/operator-framework/operator-sdk/issues/3447
 	// https://github.com/operator-framework/operator-sdk/issues/3447
@@ -94,6 +227,15 @@ func getWebURL(url string) string {
 	return strings.Replace(strings.Replace(url, "api.github.com", "github.com", 1), "repos/", "", 1)
 }
 
+// issueWebURL returns the web URL of the issue. It prefers the html url Github
+// returns, which is correct for any Github host, over rewriting the API URL.
+func issueWebURL(issue *github.Issue) string {
+	if u := issue.GetHTMLURL(); u != "" {
+		return u
+	}
+	return getWebURL(issue.GetURL())
+}
+
 func Clone(issue *github.Issue, opts ...Option) (*gojira.Issue, error) {
 	config := ClonerConfig{}
 	for _, opt := range opts {
@@ -111,6 +253,23 @@ func Clone(issue *github.Issue, opts ...Option) (*gojira.Issue, error) {
 		return nil, err
 	}
 
+	if config.resolver == nil {
+		config.resolver = &jqlResolver{client: jiraClient, webBase: webBase(issue)}
+		if config.store != nil {
+			config.resolver = resolvers{config.store, config.resolver}
+		}
+	}
+
+	links := resolveReferences(issue, config.resolver)
+	body := rewriteReferences(issue.GetBody(), links)
+
+	// a dry run does not look the watchers up in jira
+	names := watcherNames(issue, &config)
+	var watchers []watcher
+	if len(names) > 0 && !config.dryRun {
+		watchers = resolveWatchers(jiraClient, names, config.authMode == AuthBasic)
+	}
+
 	ji := gojira.Issue{
 		Fields: &gojira.IssueFields{
 			// Assignee: &gojira.User{
@@ -119,7 +278,8 @@ func Clone(issue *github.Issue, opts ...Option) (*gojira.Issue, error) {
 			// Reporter: &gojira.User{
 			//     Name: "youruser",
 			// },
-			Description: fmt.Sprintf("%s\n\nUpstream Github issue: %s\n", issue.GetBody(), getWebURL(issue.GetURL())),
+			Description: fmt.Sprintf("%s\n\nUpstream Github issue: %s\n",
+				body, issueWebURL(issue)),
 			Type: gojira.IssueType{
 				Name: "Story",
 			},
@@ -130,30 +290,120 @@ func Clone(issue *github.Issue, opts ...Option) (*gojira.Issue, error) {
 		},
 	}
 
+	policy, err := config.sensitive.apply(issue, &ji)
+	if err != nil {
+		if !config.dryRun {
+			return nil, err
+		}
+		// show what a real run would do rather than stopping the dry run
+		fmt.Fprintln(redact.Stdout, "\n############# DRY RUN MODE #############")
+		fmt.Fprintf(redact.Stdout, "Cloning issue #%d to jira project board: %s\n\n", issue.GetNumber(), ji.Fields.Project.Key)
+		fmt.Fprintf(redact.Stdout, "Sensitive issue policy: %v\n", err)
+		fmt.Fprintln(redact.Stdout, "A real run would refuse to clone this issue.")
+		fmt.Fprintln(redact.Stdout, "\n############# DRY RUN MODE #############")
+		return nil, nil
+	}
+
+	var doc *adf.Node
+	if config.apiVersion == APIv3 {
+		doc = descriptionDocument(body, issueWebURL(issue), webBase(issue))
+		ji.Fields.Description = ""
+		if ji.Fields.Unknowns == nil {
+			ji.Fields.Unknowns = map[string]interface{}{}
+		}
+		ji.Fields.Unknowns["description"] = doc
+	}
+
 	var daIssue *gojira.Issue
 
 	if config.dryRun {
-		fmt.Println("\n############# DRY RUN MODE #############")
-		fmt.Printf("Cloning issue #%d to jira project board: %s\n\n", issue.GetNumber(), ji.Fields.Project.Key)
-		fmt.Printf("Summary: %s\n", ji.Fields.Summary)
-		fmt.Printf("Type: %s\n", ji.Fields.Type.Name)
-		fmt.Println("Description:")
-		fmt.Printf("%s\n", ji.Fields.Description)
-		fmt.Println("\n############# DRY RUN MODE #############")
+		fmt.Fprintln(redact.Stdout, "\n############# DRY RUN MODE #############")
+		fmt.Fprintf(redact.Stdout, "Cloning issue #%d to jira project board: %s\n\n", issue.GetNumber(), ji.Fields.Project.Key)
+		fmt.Fprintf(redact.Stdout, "Summary: %s\n", ji.Fields.Summary)
+		fmt.Fprintf(redact.Stdout, "Type: %s\n", ji.Fields.Type.Name)
+		if policy != "" {
+			fmt.Fprintf(redact.Stdout, "Sensitive issue policy: %s\n", policy)
+		}
+		fmt.Fprintln(redact.Stdout, "Description:")
+		if doc != nil {
+			out, err := json.MarshalIndent(doc, "", "  ")
+			if err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(redact.Stdout, "%s\n", out)
+		} else {
+			fmt.Fprintf(redact.Stdout, "%s\n", ji.Fields.Description)
+		}
+		if len(links) > 0 {
+			fmt.Fprintln(redact.Stdout, "Links:")
+			for _, l := range uniqueLinks(links) {
+				fmt.Fprintf(redact.Stdout, "  %s\n", describeLink(l))
+			}
+		}
+		if len(names) > 0 {
+			fmt.Fprintf(redact.Stdout, "Watchers: %s (not looked up in jira)\n", strings.Join(names, ", "))
+		}
+		if len(config.comments) > 0 {
+			fmt.Fprintf(redact.Stdout, "Comments: %d to copy\n", len(config.comments))
+		}
+		fmt.Fprintln(redact.Stdout, "\n############# DRY RUN MODE #############")
 	} else {
-		fmt.Printf("Cloning issue #%d to jira project board: %s\n\n", issue.GetNumber(), ji.Fields.Project.Key)
-		var err error
-		daIssue, _, err = jiraClient.Issue.Create(&ji)
+		fmt.Fprintf(redact.Stdout, "Cloning issue #%d to jira project board: %s\n\n", issue.GetNumber(), ji.Fields.Project.Key)
+		if policy != "" {
+			fmt.Fprintf(redact.Stdout, "Sensitive issue policy: %s\n", policy)
+		}
+		if config.apiVersion == APIv3 {
+			daIssue, err = createIssueV3(jiraClient, &ji)
+		} else {
+			daIssue, _, err = jiraClient.Issue.Create(&ji)
+		}
 		if err != nil {
-			fmt.Printf("Error cloning issue: %v", err)
+			fmt.Fprintf(redact.Stdout, "Error cloning issue: %v", err)
 			return daIssue, err
 		}
 
 		if daIssue != nil {
-			fmt.Printf("Issue cloned; see %s\n",
-				fmt.Sprintf("https://issues.redhat.com/browse/%s", daIssue.Key))
+			fmt.Fprintf(redact.Stdout, "Issue cloned; see %s\n", browseURL(config.jiraURL, daIssue.Key))
+
+			if config.store != nil {
+				if err := recordClone(config.store, issue, daIssue.Key); err != nil {
+					return daIssue, fmt.Errorf("issue cloned to %s but could not be recorded in %s: %w",
+						daIssue.Key, config.store.Path(), err)
+				}
+			}
+
+			for _, l := range uniqueLinks(links) {
+				if _, err := jiraClient.Issue.AddLink(newIssueLink(daIssue.Key, l)); err != nil {
+					fmt.Fprintf(redact.Stdout, "Warning: unable to link %s to %s: %v\n", daIssue.Key, l.key, err)
+					continue
+				}
+				fmt.Fprintf(redact.Stdout, "Linked: %s %s\n", daIssue.Key, describeLink(l))
+			}
+
+			addWatchers(jiraClient, daIssue.Key, watchers)
+			addComments(jiraClient, daIssue.Key, config.comments, config.apiVersion, webBase(issue))
 		}
 	}
 
 	return daIssue, nil
 }
+
+// browseURL returns the web page of the Jira issue.
+func browseURL(jiraURL, key string) string {
+	return strings.TrimSuffix(jiraURL, "/") + "/browse/" + key
+}
+
+func recordClone(store *mapping.Store, issue *github.Issue, key string) error {
+	org, repo := gh.IssueRepo(issue)
+	now := time.Now().UTC()
+	return store.Put(mapping.Record{
+		Org:       org,
+		Repo:      repo,
+		Number:    issue.GetNumber(),
+		JiraKey:   key,
+		ClonedAt:  now,
+		SyncedAt:  now,
+		TitleHash: mapping.Hash(issue.GetTitle()),
+		BodyHash:  mapping.Hash(issue.GetBody()),
+	})
+}
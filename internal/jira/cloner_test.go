@@ -15,15 +15,20 @@
 package jira
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 
 	gojira "github.com/andygrunwald/go-jira"
 	"github.com/google/go-github/v47/github"
 	jmock "github.com/jmrodri/gh2jira/internal/jira/mock"
+	"github.com/jmrodri/gh2jira/internal/mapping"
+	"github.com/jmrodri/gh2jira/internal/redact"
+	"github.com/jmrodri/gh2jira/internal/transport"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -91,6 +96,86 @@ var _ = Describe("Cloner", func() {
 				Expect(options.jiraURL).To(Equal(url))
 			})
 		})
+		Describe("WithTransport", func() {
+			It("should set the transport config", func() {
+				t := &transport.Config{Proxy: "http://proxy:3128"}
+				opt := WithTransport(t)
+				err := opt(&options)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(options.transport).To(Equal(t))
+			})
+			It("should fail to create a client with a bad transport", func() {
+				_, err := Clone(nil, WithToken("token"),
+					WithTransport(&transport.Config{Proxy: "bad proxy"}))
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("invalid proxy url"))
+			})
+		})
+		Describe("WithWatchers", func() {
+			It("should set the watchers", func() {
+				opt := WithWatchers([]string{"lead"})
+				err := opt(&options)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(options.watchers).To(Equal([]string{"lead"}))
+			})
+		})
+		Describe("WithWatchAssignees", func() {
+			It("should set watch assignees", func() {
+				opt := WithWatchAssignees(true)
+				err := opt(&options)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(options.watchAssignees).To(BeTrue())
+			})
+		})
+		Describe("WithParticipants", func() {
+			It("should set the participants", func() {
+				opt := WithParticipants([]string{"johndoe"})
+				err := opt(&options)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(options.participants).To(Equal([]string{"johndoe"}))
+			})
+		})
+		Describe("WithUserMap", func() {
+			It("should set the user map", func() {
+				m := map[string]string{"johndoe": "jdoe"}
+				opt := WithUserMap(m)
+				err := opt(&options)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(options.userMap).To(Equal(m))
+			})
+		})
+		Describe("WithAPIVersion", func() {
+			It("should set the api version", func() {
+				opt := WithAPIVersion(APIv3)
+				err := opt(&options)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(options.apiVersion).To(Equal(APIv3))
+			})
+			It("should reject unknown versions", func() {
+				opt := WithAPIVersion(4)
+				err := opt(&options)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("unsupported jira api version 4"))
+			})
+		})
+		Describe("WithComments", func() {
+			It("should set the comments", func() {
+				comments := []*github.IssueComment{{Body: github.String("me too")}}
+				opt := WithComments(comments)
+				err := opt(&options)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(options.comments).To(Equal(comments))
+			})
+		})
+		Describe("WithMappingStore", func() {
+			It("should set the mapping store", func() {
+				store, _ := mapping.Open("/tmp/mapping.json")
+				opt := WithMappingStore(store)
+				err := opt(&options)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(options.store).To(Equal(store))
+			})
+		})
 	})
 
 	Describe("getWebURL", func() {
@@ -119,6 +204,25 @@ var _ = Describe("Cloner", func() {
 		})
 	})
 
+	Describe("issueWebURL", func() {
+		It("should prefer the html url", func() {
+			issue := &github.Issue{
+				URL:     github.String("https://github.example.com/api/v3/repos/foo/bar/issues/1"),
+				HTMLURL: github.String("https://github.example.com/foo/bar/issues/1"),
+			}
+			Expect(issueWebURL(issue)).To(Equal("https://github.example.com/foo/bar/issues/1"))
+			Expect(webBase(issue)).To(Equal("https://github.example.com"))
+		})
+		It("should fall back to converting the api url", func() {
+			issue := &github.Issue{
+				URL: github.String("https://api.github.com/repos/foo/bar/issues/1"),
+			}
+			Expect(issueWebURL(issue)).To(Equal("https://github.com/foo/bar/issues/1"))
+			Expect(webBase(issue)).To(Equal("https://github.com"))
+			Expect(webBase(&github.Issue{})).To(Equal("https://github.com"))
+		})
+	})
+
 	Describe("Clone", func() {
 		It("shoud return an error if there is no token", func() {
 			_, err := Clone(nil)
@@ -160,6 +264,65 @@ var _ = Describe("Cloner", func() {
 
 			Expect(strings.Contains(string(stdout), "DRY RUN MODE")).To(BeTrue())
 		})
+		It("should not print the tokens in dry run", func() {
+			redact.Add("jira-secret-token")
+			defer redact.Reset()
+
+			ghissue := &github.Issue{
+				Number: github.Int(123),
+				Title:  github.String("Leaked jira-secret-token"),
+				Body:   github.String("curl -H 'Authorization: Bearer jira-secret-token'"),
+				URL:    github.String("https://api.github.com/repos/foo/bar/issues/123"),
+			}
+
+			r, w, _ := os.Pipe()
+			tmp := os.Stdout
+			defer func() {
+				os.Stdout = tmp
+			}()
+			os.Stdout = w
+			go func() {
+				_, err := Clone(ghissue, WithToken("jira-secret-token"),
+					WithDryRun(true),
+					WithJiraURL("http://localhost"),
+				)
+				w.Close()
+				Expect(err).NotTo(HaveOccurred())
+			}()
+			stdout, _ := io.ReadAll(r)
+
+			Expect(string(stdout)).To(ContainSubstring("DRY RUN MODE"))
+			Expect(string(stdout)).NotTo(ContainSubstring("jira-secret-token"))
+		})
+		It("should not print the token in request errors", func() {
+			redact.Add("jira-secret-token")
+			defer redact.Reset()
+
+			ghissue := &github.Issue{
+				Number: github.Int(123),
+				Title:  github.String("Issue 1"),
+				URL:    github.String("https://api.github.com/repos/foo/bar/issues/123"),
+			}
+
+			r, w, _ := os.Pipe()
+			tmp := os.Stdout
+			defer func() {
+				os.Stdout = tmp
+			}()
+			os.Stdout = w
+			go func() {
+				// the url, token included, ends up in the error of the request
+				_, err := Clone(ghissue, WithToken("jira-secret-token"),
+					WithJiraURL("http://127.0.0.1:1/jira-secret-token/"),
+				)
+				w.Close()
+				Expect(err).To(HaveOccurred())
+			}()
+			stdout, _ := io.ReadAll(r)
+
+			Expect(string(stdout)).To(ContainSubstring("Error cloning issue"))
+			Expect(string(stdout)).NotTo(ContainSubstring("jira-secret-token"))
+		})
 		It("should return an error if jira client returns an error", func() {
 			// if our request returns an error ListIssues should return
 			// that error
@@ -230,5 +393,127 @@ var _ = Describe("Cloner", func() {
 			Expect(jissue.Fields.Project).To(Equal(expectedissue.Fields.Project))
 			Expect(jissue.Fields.Summary).To(Equal(expectedissue.Fields.Summary))
 		})
+		It("should record the cloned issue in the mapping store", func() {
+			dir, err := os.MkdirTemp("", "cloner")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+			store, err := mapping.Open(filepath.Join(dir, "mapping.json"))
+			Expect(err).NotTo(HaveOccurred())
+
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.PostIssue, gojira.Issue{Key: "OSDK-7"}),
+			)
+			ghissue := &github.Issue{
+				Number: github.Int(123),
+				Title:  github.String("Issue 1"),
+				Body:   github.String("body of the issue"),
+				URL:    github.String("https://api.github.com/repos/foo/bar/issues/123"),
+			}
+
+			_, err = Clone(ghissue, WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"),
+				WithMappingStore(store),
+			)
+			Expect(err).NotTo(HaveOccurred())
+
+			rec, err := store.Get("foo", "bar", 123)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rec).NotTo(BeNil())
+			Expect(rec.JiraKey).To(Equal("OSDK-7"))
+			Expect(rec.TitleHash).To(Equal(mapping.Hash("Issue 1")))
+			Expect(rec.ClonedAt.IsZero()).To(BeFalse())
+		})
+		It("should not record anything on a dry run", func() {
+			dir, err := os.MkdirTemp("", "cloner")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+			store, err := mapping.Open(filepath.Join(dir, "mapping.json"))
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = Clone(&github.Issue{Number: github.Int(1)},
+				WithClient(jmock.NewMockedHTTPClient()),
+				WithJiraURL("http://localhost"),
+				WithDryRun(true),
+				WithMappingStore(store),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(filepath.Join(dir, "mapping.json")).NotTo(BeAnExistingFile())
+		})
+		It("should create the issue with an ADF description using REST v3", func() {
+			var created map[string]interface{}
+			var comment map[string]interface{}
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatchHandler(
+					jmock.PostIssueV3,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						Expect(json.NewDecoder(r.Body).Decode(&created)).To(Succeed())
+						w.Write([]byte(`{"id":"10000","key":"OSDK-9"}`))
+					}),
+				),
+				jmock.WithRequestMatchHandler(
+					jmock.PostIssueCommentV3,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						Expect(r.URL.Path).To(HaveSuffix("/OSDK-9/comment"))
+						Expect(json.NewDecoder(r.Body).Decode(&comment)).To(Succeed())
+						w.WriteHeader(http.StatusCreated)
+						w.Write([]byte(`{"id":"1"}`))
+					}),
+				),
+			)
+			ghissue := &github.Issue{
+				Number:  github.Int(123),
+				Title:   github.String("Issue 1"),
+				Body:    github.String("some `code`"),
+				HTMLURL: github.String("https://github.com/foo/bar/issues/123"),
+			}
+
+			jissue, err := Clone(ghissue, WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"),
+				WithAPIVersion(APIv3),
+				WithComments([]*github.IssueComment{{
+					Body: github.String("me too"),
+					User: &github.User{Login: github.String("johndoe")},
+				}}),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(jissue.Key).To(Equal("OSDK-9"))
+
+			fields := created["fields"].(map[string]interface{})
+			Expect(fields["summary"]).To(Equal("[UPSTREAM] Issue 1 #123"))
+			desc := fields["description"].(map[string]interface{})
+			Expect(desc["type"]).To(Equal("doc"))
+			Expect(desc["content"]).To(HaveLen(2))
+
+			body := comment["body"].(map[string]interface{})
+			Expect(body["type"]).To(Equal("doc"))
+			out, _ := json.Marshal(body)
+			Expect(string(out)).To(ContainSubstring("Comment by @johndoe"))
+			Expect(string(out)).To(ContainSubstring("me too"))
+		})
+		It("should copy comments as plain text using REST v2", func() {
+			var comment gojira.Comment
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.PostIssue, gojira.Issue{Key: "OSDK-7"}),
+				jmock.WithRequestMatchHandler(
+					jmock.PostIssueComment,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						Expect(json.NewDecoder(r.Body).Decode(&comment)).To(Succeed())
+						w.WriteHeader(http.StatusCreated)
+						w.Write([]byte(`{"id":"1"}`))
+					}),
+				),
+			)
+			_, err := Clone(&github.Issue{Number: github.Int(1)},
+				WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"),
+				WithComments([]*github.IssueComment{{
+					Body: github.String("me too"),
+					User: &github.User{Login: github.String("johndoe")},
+				}}),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(comment.Body).To(HavePrefix("Comment by @johndoe on "))
+			Expect(comment.Body).To(HaveSuffix(":\n\nme too"))
+		})
 	})
 })
@@ -0,0 +1,111 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"fmt"
+
+	gojira "github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v47/github"
+
+	"github.com/jmrodri/gh2jira/internal/adf"
+	"github.com/jmrodri/gh2jira/internal/redact"
+)
+
+const (
+	// APIv2 is the Jira Server and Data Center REST API. Descriptions and
+	// comments are plain wiki markup strings.
+	APIv2 = 2
+	// APIv3 is the Jira Cloud REST API. Descriptions and comments are
+	// Atlassian Document Format documents.
+	APIv3 = 3
+)
+
+// commentHeader says who wrote the upstream comment and when since the clone
+// is posted by the owner of the jira token.
+func commentHeader(c *github.IssueComment) string {
+	return fmt.Sprintf("Comment by @%s on %s:", c.GetUser().GetLogin(),
+		c.GetCreatedAt().UTC().Format("2006-01-02 15:04 MST"))
+}
+
+// descriptionDocument renders the description of the clone as an ADF document.
+func descriptionDocument(body, issueURL, mentionURL string) *adf.Node {
+	doc := adf.Render(body, adf.Options{MentionURL: mentionURL})
+	doc.Content = append(doc.Content, adf.Paragraph(
+		adf.Text("Upstream Github issue: "),
+		adf.Text(issueURL, adf.Link(issueURL)),
+	))
+	return doc
+}
+
+// commentDocument renders the upstream comment as an ADF document.
+func commentDocument(c *github.IssueComment, mentionURL string) *adf.Node {
+	doc := adf.Render(c.GetBody(), adf.Options{MentionURL: mentionURL})
+	header := adf.Paragraph(adf.Text(commentHeader(c), adf.Mark{Type: "strong"}))
+	doc.Content = append([]*adf.Node{header}, doc.Content...)
+	return doc
+}
+
+// createIssueV3 creates the issue with the REST v3 API. The description must
+// already be set as an ADF document in the unknown fields.
+func createIssueV3(client *gojira.Client, ji *gojira.Issue) (*gojira.Issue, error) {
+	req, err := client.NewRequest("POST", "rest/api/3/issue", ji)
+	if err != nil {
+		return nil, err
+	}
+	created := new(gojira.Issue)
+	resp, err := client.Do(req, created)
+	if err != nil {
+		return nil, gojira.NewJiraError(resp, err)
+	}
+	return created, nil
+}
+
+// addComments copies the upstream comments to the jira issue. Comments that
+// cannot be added are skipped with a warning.
+func addComments(client *gojira.Client, key string, comments []*github.IssueComment, apiVersion int, mentionURL string) {
+	copied := 0
+	for _, c := range comments {
+		var err error
+		if apiVersion == APIv3 {
+			err = addCommentV3(client, key, commentDocument(c, mentionURL))
+		} else {
+			_, _, err = client.Issue.AddComment(key, &gojira.Comment{
+				Body: fmt.Sprintf("%s\n\n%s", commentHeader(c), c.GetBody()),
+			})
+		}
+		if err != nil {
+			fmt.Fprintf(redact.Stdout, "Warning: unable to copy comment %d to %s: %v\n", c.GetID(), key, err)
+			continue
+		}
+		copied++
+	}
+	if copied > 0 {
+		fmt.Fprintf(redact.Stdout, "Comments copied: %d\n", copied)
+	}
+}
+
+func addCommentV3(client *gojira.Client, key string, body *adf.Node) error {
+	req, err := client.NewRequest("POST", fmt.Sprintf("rest/api/3/issue/%s/comment", key),
+		map[string]interface{}{"body": body})
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req, nil)
+	if err != nil {
+		return gojira.NewJiraError(resp, err)
+	}
+	return nil
+}
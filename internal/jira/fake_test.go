@@ -0,0 +1,133 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	gojira "github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v47/github"
+
+	jmock "github.com/jmrodri/gh2jira/internal/jira/mock"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Fake jira", func() {
+	var (
+		fake *jmock.Fake
+		opts []Option
+	)
+
+	BeforeEach(func() {
+		fake = jmock.NewFake(
+			jmock.WithFakeProject("OSDK", "Operator SDK"),
+			jmock.WithFakeUser(gojira.User{Name: "jdoe"}),
+			jmock.WithFakeUser(gojira.User{Name: "jsmith"}),
+		)
+		opts = []Option{
+			WithClient(jmock.NewFakeHTTPClient(fake)),
+			WithJiraURL("http://localhost"),
+			WithProject("OSDK"),
+		}
+	})
+
+	ghIssue := func(number int, body string) *github.Issue {
+		return &github.Issue{
+			Number:  github.Int(number),
+			Title:   github.String("Issue"),
+			Body:    github.String(body),
+			HTMLURL: github.String(fmt.Sprintf("https://github.com/foo/bar/issues/%d", number)),
+		}
+	}
+
+	It("should clone, link, watch and comment end to end", func() {
+		first, err := Clone(ghIssue(1, "first"), opts...)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(first.Key).To(Equal("OSDK-1"))
+
+		second, err := Clone(ghIssue(2, "blocked by #1"), append(opts,
+			WithWatchers([]string{"jsmith", "nobody"}),
+			WithComments([]*github.IssueComment{{
+				Body: github.String("me too"),
+				User: &github.User{Login: github.String("octocat")},
+			}}),
+		)...)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(second.Key).To(Equal("OSDK-2"))
+
+		// the link to #1 was found by searching the descriptions
+		issue, ok := fake.Issue("OSDK-2")
+		Expect(ok).To(BeTrue())
+		Expect(issue.Fields.Summary).To(Equal("[UPSTREAM] Issue #2"))
+		Expect(issue.Fields.IssueLinks).To(HaveLen(1))
+		Expect(issue.Fields.IssueLinks[0].Type.Name).To(Equal("Blocks"))
+		Expect(issue.Fields.IssueLinks[0].InwardIssue.Key).To(Equal("OSDK-1"))
+		Expect(fake.Watchers("OSDK-2")).To(Equal([]string{"jsmith"}))
+		Expect(issue.Fields.Comments.Comments).To(HaveLen(1))
+		Expect(issue.Fields.Comments.Comments[0].Body).To(ContainSubstring("me too"))
+
+		found, err := GetIssues([]string{"OSDK-1", "OSDK-2", "OSDK-3"}, opts...)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(HaveLen(2))
+		Expect(IsDone(found["OSDK-1"])).To(BeFalse())
+	})
+
+	It("should print the link to the clone on the configured server", func() {
+		r, w, _ := os.Pipe()
+		tmp := os.Stdout
+		defer func() {
+			os.Stdout = tmp
+		}()
+		os.Stdout = w
+		go func() {
+			defer GinkgoRecover()
+			_, err := Clone(ghIssue(1, "first"), append(opts,
+				WithJiraURL("https://example.atlassian.net/"))...)
+			w.Close()
+			Expect(err).NotTo(HaveOccurred())
+		}()
+		stdout, _ := io.ReadAll(r)
+
+		Expect(string(stdout)).To(ContainSubstring("Issue cloned; see https://example.atlassian.net/browse/OSDK-1\n"))
+	})
+
+	It("should clone with the v3 API", func() {
+		_, err := Clone(ghIssue(1, "**bold** body"), append(opts,
+			WithAPIVersion(APIv3),
+			WithComments([]*github.IssueComment{{Body: github.String("me *too*")}}),
+		)...)
+		Expect(err).NotTo(HaveOccurred())
+
+		issue, ok := fake.Issue("OSDK-1")
+		Expect(ok).To(BeTrue())
+		Expect(issue.Fields.Description).To(ContainSubstring("bold body"))
+		Expect(issue.Fields.Description).To(ContainSubstring("https://github.com/foo/bar/issues/1"))
+		Expect(issue.Fields.Comments.Comments).To(HaveLen(1))
+	})
+
+	It("should check the permissions in existing projects", func() {
+		have, err := MyPermissions("OSDK", RequiredPermissions, opts...)
+		Expect(err).NotTo(HaveOccurred())
+		for _, p := range RequiredPermissions {
+			Expect(have[p]).To(BeTrue(), p)
+		}
+		_, err = MyPermissions("NOPE", RequiredPermissions, opts...)
+		Expect(err).To(HaveOccurred())
+	})
+})
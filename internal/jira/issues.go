@@ -0,0 +1,75 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"fmt"
+	"strings"
+
+	gojira "github.com/andygrunwald/go-jira"
+)
+
+// keysPerSearch limits how many keys are put in a single JQL query
+const keysPerSearch = 50
+
+// GetIssues returns the summary and status of the given Jira issues keyed by
+// issue key. Keys that do not exist are missing from the result.
+func GetIssues(keys []string, opts ...Option) (map[string]*gojira.Issue, error) {
+	config := ClonerConfig{}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.setDefaults(); err != nil {
+		return nil, err
+	}
+
+	jiraClient, err := gojira.NewClient(config.client, config.jiraURL)
+	if err != nil {
+		return nil, err
+	}
+
+	found := map[string]*gojira.Issue{}
+	for start := 0; start < len(keys); start += keysPerSearch {
+		end := start + keysPerSearch
+		if end > len(keys) {
+			end = len(keys)
+		}
+		// validateQuery=warn keeps jira from failing the whole search when
+		// one of the issues has since been deleted or moved
+		jql := fmt.Sprintf("key in (%s)", strings.Join(keys[start:end], ","))
+		issues, _, err := jiraClient.Issue.Search(jql, &gojira.SearchOptions{
+			MaxResults:    end - start,
+			Fields:        []string{"summary", "status"},
+			ValidateQuery: "warn",
+		})
+		if err != nil {
+			return nil, err
+		}
+		for i := range issues {
+			found[issues[i].Key] = &issues[i]
+		}
+	}
+	return found, nil
+}
+
+// IsDone returns true if the issue is in a status of the done category, e.g.
+// Closed or Resolved.
+func IsDone(issue *gojira.Issue) bool {
+	return issue != nil && issue.Fields != nil && issue.Fields.Status != nil &&
+		issue.Fields.Status.StatusCategory.Key == gojira.StatusCategoryComplete
+}
@@ -0,0 +1,93 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"fmt"
+	"net/http"
+
+	gojira "github.com/andygrunwald/go-jira"
+	jmock "github.com/jmrodri/gh2jira/internal/jira/mock"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Issues", func() {
+	Describe("GetIssues", func() {
+		It("should return an error if there is no token", func() {
+			_, err := GetIssues([]string{"OSDK-1"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("cannot create jira client without a token"))
+		})
+		It("should search for the keys in batches", func() {
+			var queries []string
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatchHandler(jmock.GetSearch,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						queries = append(queries, r.URL.Query().Get("jql"))
+						w.Write(jmock.MustMarshal(map[string]interface{}{
+							"issues": []gojira.Issue{
+								{
+									Key: fmt.Sprintf("OSDK-%d", len(queries)),
+									Fields: &gojira.IssueFields{
+										Status: &gojira.Status{
+											Name: "Closed",
+											StatusCategory: gojira.StatusCategory{
+												Key: gojira.StatusCategoryComplete,
+											},
+										},
+									},
+								},
+							},
+						}))
+					}),
+				),
+			)
+
+			var keys []string
+			for i := 1; i <= 60; i++ {
+				keys = append(keys, fmt.Sprintf("OSDK-%d", i))
+			}
+			issues, err := GetIssues(keys, WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(queries).To(HaveLen(2))
+			Expect(queries[1]).To(Equal("key in (OSDK-51,OSDK-52,OSDK-53,OSDK-54,OSDK-55," +
+				"OSDK-56,OSDK-57,OSDK-58,OSDK-59,OSDK-60)"))
+			Expect(issues).To(HaveLen(2))
+			Expect(IsDone(issues["OSDK-1"])).To(BeTrue())
+		})
+		It("should return an error if the search fails", func() {
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatchHandler(jmock.GetSearch,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						jmock.WriteError(w, http.StatusInternalServerError, "boom")
+					}),
+				),
+			)
+			_, err := GetIssues([]string{"OSDK-1"}, WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+	Describe("IsDone", func() {
+		It("should handle issues without a status", func() {
+			Expect(IsDone(nil)).To(BeFalse())
+			Expect(IsDone(&gojira.Issue{})).To(BeFalse())
+			Expect(IsDone(&gojira.Issue{Fields: &gojira.IssueFields{}})).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,198 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	gojira "github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v47/github"
+
+	"github.com/jmrodri/gh2jira/internal/gh"
+	"github.com/jmrodri/gh2jira/internal/redact"
+)
+
+const (
+	relatesLinkType = "Relates"
+	blocksLinkType  = "Blocks"
+	defaultWebBase  = "https://github.com"
+)
+
+// IssueResolver finds the Jira issue a Github issue was cloned to. Resolve
+// returns an empty key if the Github issue has not been cloned.
+type IssueResolver interface {
+	Resolve(org, repo string, number int) (string, error)
+}
+
+// resolvers tries each resolver in turn until one finds the issue.
+type resolvers []IssueResolver
+
+func (rs resolvers) Resolve(org, repo string, number int) (string, error) {
+	for _, r := range rs {
+		key, err := r.Resolve(org, repo, number)
+		if err != nil || key != "" {
+			return key, err
+		}
+	}
+	return "", nil
+}
+
+// jqlResolver finds cloned issues by searching Jira for the upstream Github
+// issue URL that Clone puts in every description.
+type jqlResolver struct {
+	client *gojira.Client
+	// webBase is the scheme and host of the Github web UI, e.g.
+	// https://github.com
+	webBase string
+}
+
+func (r *jqlResolver) Resolve(org, repo string, number int) (string, error) {
+	base := r.webBase
+	if base == "" {
+		base = defaultWebBase
+	}
+	issueURL := fmt.Sprintf("%s/%s/%s/issues/%d", base, org, repo, number)
+	jql := fmt.Sprintf("description ~ \"\\\"%s\\\"\"", issueURL)
+
+	issues, _, err := r.client.Issue.Search(jql, &gojira.SearchOptions{
+		MaxResults: 10,
+		Fields:     []string{"description"},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Jira's text search is fuzzy, make sure the description really points
+	// at this issue and not at e.g. issues/1234 when looking for issues/123.
+	// The description ends with the upstream URL, as a plain string when
+	// created with REST v2 or as a link, rendered [url|url] by v2 searches,
+	// when created with REST v3. Either way another issue number must not
+	// follow.
+	marker := regexp.MustCompile(`Upstream Github issue: \[?` + regexp.QuoteMeta(issueURL) + `(\D|$)`)
+	for _, issue := range issues {
+		if issue.Fields != nil && marker.MatchString(issue.Fields.Description) {
+			return issue.Key, nil
+		}
+	}
+	return "", nil
+}
+
+// webBase returns the scheme and host of the web URL of the issue so issues it
+// references on the same Github instance can be found.
+func webBase(issue *github.Issue) string {
+	u, err := url.Parse(issueWebURL(issue))
+	if err != nil || u.Host == "" {
+		return defaultWebBase
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// issueLink is a Github reference that has a Jira counterpart.
+type issueLink struct {
+	ref gh.Reference
+	key string
+}
+
+// resolveReferences finds the Github issues referenced in the body of the
+// given issue that have already been cloned to Jira. References that cannot
+// be resolved are skipped with a warning.
+func resolveReferences(issue *github.Issue, resolver IssueResolver) []issueLink {
+	org, repo := gh.IssueRepo(issue)
+
+	var links []issueLink
+	keys := map[string]string{}
+	for _, ref := range gh.FindReferences(issue.GetBody(), org, repo) {
+		if ref.Org == org && ref.Repo == repo && ref.Number == issue.GetNumber() {
+			// the issue is referring to itself
+			continue
+		}
+
+		id := fmt.Sprintf("%s/%s#%d", ref.Org, ref.Repo, ref.Number)
+		key, ok := keys[id]
+		if !ok {
+			var err error
+			key, err = resolver.Resolve(ref.Org, ref.Repo, ref.Number)
+			if err != nil {
+				fmt.Fprintf(redact.Stdout, "Warning: unable to look up %s in jira: %v\n", id, err)
+			}
+			keys[id] = key
+		}
+		if key == "" {
+			continue
+		}
+
+		links = append(links, issueLink{ref: ref, key: key})
+	}
+	return links
+}
+
+// rewriteReferences appends the Jira key to every resolved reference in body.
+func rewriteReferences(body string, links []issueLink) string {
+	var sb strings.Builder
+	last := 0
+	for _, l := range links {
+		sb.WriteString(body[last:l.ref.End])
+		sb.WriteString(fmt.Sprintf(" (%s)", l.key))
+		last = l.ref.End
+	}
+	sb.WriteString(body[last:])
+	return sb.String()
+}
+
+// uniqueLinks returns one link per Jira key. A blocking reference wins over a
+// plain one when an issue is mentioned more than once.
+func uniqueLinks(links []issueLink) []issueLink {
+	var unique []issueLink
+	index := map[string]int{}
+	for _, l := range links {
+		if i, ok := index[l.key]; ok {
+			if l.ref.Kind == gh.BlockedBy {
+				unique[i] = l
+			}
+			continue
+		}
+		index[l.key] = len(unique)
+		unique = append(unique, l)
+	}
+	return unique
+}
+
+// newIssueLink creates the Jira link between the cloned issue and the one it
+// references.
+func newIssueLink(key string, l issueLink) *gojira.IssueLink {
+	if l.ref.Kind == gh.BlockedBy {
+		// Jira reads the link as "inwardIssue blocks outwardIssue"
+		return &gojira.IssueLink{
+			Type:         gojira.IssueLinkType{Name: blocksLinkType},
+			InwardIssue:  &gojira.Issue{Key: l.key},
+			OutwardIssue: &gojira.Issue{Key: key},
+		}
+	}
+	return &gojira.IssueLink{
+		Type:         gojira.IssueLinkType{Name: relatesLinkType},
+		InwardIssue:  &gojira.Issue{Key: key},
+		OutwardIssue: &gojira.Issue{Key: l.key},
+	}
+}
+
+func describeLink(l issueLink) string {
+	if l.ref.Kind == gh.BlockedBy {
+		return fmt.Sprintf("is blocked by %s (%s)", l.key, l.ref.Text)
+	}
+	return fmt.Sprintf("relates to %s (%s)", l.key, l.ref.Text)
+}
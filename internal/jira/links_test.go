@@ -0,0 +1,184 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	gojira "github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v47/github"
+	"github.com/jmrodri/gh2jira/internal/gh"
+	jmock "github.com/jmrodri/gh2jira/internal/jira/mock"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// fakeResolver resolves references from a map of org/repo#num to jira key
+type fakeResolver map[string]string
+
+func (f fakeResolver) Resolve(org, repo string, number int) (string, error) {
+	return f[fmt.Sprintf("%s/%s#%d", org, repo, number)], nil
+}
+
+var _ = Describe("Links", func() {
+	var ghissue *github.Issue
+
+	BeforeEach(func() {
+		ghissue = &github.Issue{
+			Number: github.Int(123),
+			Title:  github.String("Issue 1"),
+			Body: github.String("see #10 and #11, blocked by other/repo#5\n" +
+				"also #10 again and myself #123"),
+			URL: github.String("https://api.github.com/repos/foo/bar/issues/123"),
+		}
+	})
+
+	Describe("resolveReferences", func() {
+		It("should only return references that have been cloned", func() {
+			links := resolveReferences(ghissue, fakeResolver{
+				"foo/bar#10":   "OSDK-10",
+				"other/repo#5": "OSDK-5",
+				"foo/bar#123":  "OSDK-123",
+			})
+			Expect(links).To(HaveLen(3))
+			Expect(links[0].key).To(Equal("OSDK-10"))
+			Expect(links[1].key).To(Equal("OSDK-5"))
+			Expect(links[1].ref.Kind).To(Equal(gh.BlockedBy))
+			Expect(links[2].key).To(Equal("OSDK-10"))
+			Expect(uniqueLinks(links)).To(HaveLen(2))
+		})
+	})
+
+	Describe("rewriteReferences", func() {
+		It("should add the jira key after each resolved reference", func() {
+			links := resolveReferences(ghissue, fakeResolver{
+				"foo/bar#10":   "OSDK-10",
+				"other/repo#5": "OSDK-5",
+			})
+			Expect(rewriteReferences(ghissue.GetBody(), links)).To(Equal(
+				"see #10 (OSDK-10) and #11, blocked by other/repo#5 (OSDK-5)\n" +
+					"also #10 (OSDK-10) again and myself #123"))
+		})
+	})
+
+	Describe("newIssueLink", func() {
+		It("should create a relates link", func() {
+			link := newIssueLink("OSDK-1", issueLink{key: "OSDK-2"})
+			Expect(link.Type.Name).To(Equal("Relates"))
+			Expect(link.InwardIssue.Key).To(Equal("OSDK-1"))
+			Expect(link.OutwardIssue.Key).To(Equal("OSDK-2"))
+		})
+		It("should make the referenced issue block the clone", func() {
+			link := newIssueLink("OSDK-1", issueLink{
+				key: "OSDK-2",
+				ref: gh.Reference{Kind: gh.BlockedBy},
+			})
+			Expect(link.Type.Name).To(Equal("Blocks"))
+			Expect(link.InwardIssue.Key).To(Equal("OSDK-2"))
+			Expect(link.OutwardIssue.Key).To(Equal("OSDK-1"))
+		})
+	})
+
+	Describe("jqlResolver", func() {
+		It("should only match the exact upstream url", func() {
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch,
+					map[string]interface{}{
+						"issues": []gojira.Issue{
+							{
+								Key: "OSDK-1234",
+								Fields: &gojira.IssueFields{
+									Description: "Upstream Github issue: " +
+										"https://github.com/foo/bar/issues/1234\n",
+								},
+							},
+							{
+								Key: "OSDK-123",
+								Fields: &gojira.IssueFields{
+									Description: "Upstream Github issue: " +
+										"https://github.com/foo/bar/issues/123\n",
+								},
+							},
+						},
+					},
+				),
+			)
+			client, err := gojira.NewClient(mockedHTTPClient, "http://localhost")
+			Expect(err).NotTo(HaveOccurred())
+
+			key, err := (&jqlResolver{client: client}).Resolve("foo", "bar", 123)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(key).To(Equal("OSDK-123"))
+		})
+		It("should match issues cloned with an ADF description", func() {
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.GetSearch,
+					map[string]interface{}{
+						"issues": []gojira.Issue{
+							{
+								Key: "OSDK-123",
+								Fields: &gojira.IssueFields{
+									Description: "Upstream Github issue: " +
+										"[https://github.com/foo/bar/issues/123|https://github.com/foo/bar/issues/123]",
+								},
+							},
+						},
+					},
+				),
+			)
+			client, err := gojira.NewClient(mockedHTTPClient, "http://localhost")
+			Expect(err).NotTo(HaveOccurred())
+
+			key, err := (&jqlResolver{client: client}).Resolve("foo", "bar", 123)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(key).To(Equal("OSDK-123"))
+		})
+	})
+
+	Describe("Clone", func() {
+		It("should link the clone to the referenced jira issues", func() {
+			var created []gojira.IssueLink
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.PostIssue, gojira.Issue{Key: "OSDK-200"}),
+				jmock.WithRequestMatchHandler(jmock.PostIssueLink,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						var link gojira.IssueLink
+						Expect(json.NewDecoder(r.Body).Decode(&link)).To(Succeed())
+						created = append(created, link)
+						w.WriteHeader(http.StatusCreated)
+					}),
+				),
+			)
+
+			_, err := Clone(ghissue, WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"),
+				WithIssueResolver(fakeResolver{
+					"foo/bar#10":   "OSDK-10",
+					"other/repo#5": "OSDK-5",
+				}),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(created).To(HaveLen(2))
+			Expect(created[0].Type.Name).To(Equal("Relates"))
+			Expect(created[0].OutwardIssue.Key).To(Equal("OSDK-10"))
+			Expect(created[1].Type.Name).To(Equal("Blocks"))
+			Expect(created[1].InwardIssue.Key).To(Equal("OSDK-5"))
+			Expect(created[1].OutwardIssue.Key).To(Equal("OSDK-200"))
+		})
+	})
+})
@@ -18,3 +18,18 @@ var PostIssue EndpointPattern = EndpointPattern{
 	Pattern: "/rest/api/2/issue",
 	Method:  "POST",
 }
+
+var PostIssueComment EndpointPattern = EndpointPattern{
+	Pattern: "/rest/api/2/issue/{issueIdOrKey}/comment",
+	Method:  "POST",
+}
+
+var PostIssueV3 EndpointPattern = EndpointPattern{
+	Pattern: "/rest/api/3/issue",
+	Method:  "POST",
+}
+
+var PostIssueCommentV3 EndpointPattern = EndpointPattern{
+	Pattern: "/rest/api/3/issue/{issueIdOrKey}/comment",
+	Method:  "POST",
+}
@@ -0,0 +1,573 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	gojira "github.com/andygrunwald/go-jira"
+	"github.com/gorilla/mux"
+)
+
+// DefaultIssueTypes are the issue types of a fake project created without
+// any.
+var DefaultIssueTypes = []string{"Bug", "Story", "Task", "Epic"}
+
+// fakeTimeFormat is how Jira writes dates.
+const fakeTimeFormat = "2006-01-02T15:04:05.000-0700"
+
+// fakeStatuses is the workflow of every fake issue, in order. Each status can
+// be reached from all the others.
+var fakeStatuses = []struct {
+	id, name, category, transition string
+}{
+	{"1", "To Do", gojira.StatusCategoryToDo, "11"},
+	{"3", "In Progress", gojira.StatusCategoryInProgress, "21"},
+	{"10001", "Done", gojira.StatusCategoryComplete, "31"},
+}
+
+// fakeLinkTypes are the issue link types of the fake.
+var fakeLinkTypes = []gojira.IssueLinkType{
+	{ID: "10000", Name: "Blocks", Inward: "is blocked by", Outward: "blocks"},
+	{ID: "10001", Name: "Cloners", Inward: "is cloned by", Outward: "clones"},
+	{ID: "10002", Name: "Duplicate", Inward: "is duplicated by", Outward: "duplicates"},
+	{ID: "10003", Name: "Relates", Inward: "relates to", Outward: "relates to"},
+}
+
+// Fake is a stateful in-memory Jira server. Unlike the request matchers it
+// keeps what is created, so a test can clone an issue, search for it, link,
+// comment and transition it, then look at the result. It serves the subset of
+// the REST v2 and v3 APIs gh2jira uses and a bit more: issues, JQL search,
+// comments, remote links, issue links, watchers, transitions, createmeta,
+// attachments, users, projects and versions.
+//
+// Example:
+//
+//	fake := NewFake(WithFakeProject("OSDK", "Operator SDK"))
+//	client := NewFakeHTTPClient(fake)
+//	jiraClient, _ := gojira.NewClient(client, "http://localhost")
+type Fake struct {
+	mu     sync.Mutex
+	router *mux.Router
+	token  string
+	log    io.Writer
+
+	projects    []*fakeProject
+	users       []*gojira.User
+	issues      []*fakeIssue
+	links       []*fakeLink
+	attachments []*fakeAttachment
+	nextID      int
+}
+
+type fakeProject struct {
+	id         string
+	key        string
+	name       string
+	issueTypes []string
+	counter    int
+	versions   []*gojira.Version
+}
+
+type fakeIssue struct {
+	id          string
+	key         string
+	project     *fakeProject
+	status      int
+	fields      map[string]interface{}
+	created     time.Time
+	updated     time.Time
+	comments    []*fakeComment
+	remoteLinks []*gojira.RemoteLink
+	watchers    []string
+}
+
+type fakeComment struct {
+	id      string
+	author  *gojira.User
+	body    interface{}
+	created time.Time
+}
+
+type fakeLink struct {
+	id       string
+	linkType gojira.IssueLinkType
+	inward   *fakeIssue
+	outward  *fakeIssue
+}
+
+type fakeAttachment struct {
+	id       string
+	issue    *fakeIssue
+	filename string
+	mimeType string
+	author   *gojira.User
+	created  time.Time
+	content  []byte
+}
+
+// FakeOption configures a Fake.
+type FakeOption func(*Fake)
+
+// WithFakeProject adds a project. Without issue types it has the
+// DefaultIssueTypes.
+func WithFakeProject(key, name string, issueTypes ...string) FakeOption {
+	return func(f *Fake) {
+		if len(issueTypes) == 0 {
+			issueTypes = DefaultIssueTypes
+		}
+		f.projects = append(f.projects, &fakeProject{
+			id:         f.newID(),
+			key:        key,
+			name:       name,
+			issueTypes: issueTypes,
+		})
+	}
+}
+
+// WithFakeVersion adds a version to a project added before.
+func WithFakeVersion(project, name string) FakeOption {
+	return func(f *Fake) {
+		if p := f.project(project); p != nil {
+			f.addVersion(p, name)
+		}
+	}
+}
+
+// WithFakeUser adds a user. The first user added is the one authenticated,
+// by default a user named gh2jira.
+func WithFakeUser(user gojira.User) FakeOption {
+	return func(f *Fake) {
+		f.addUser(user)
+	}
+}
+
+// WithFakeToken makes the fake require the token, as a bearer token or the
+// password of basic auth. By default any credentials, or none, are accepted.
+func WithFakeToken(token string) FakeOption {
+	return func(f *Fake) {
+		f.token = token
+	}
+}
+
+// WithFakeRequestLog writes a line for every request served to w.
+func WithFakeRequestLog(w io.Writer) FakeOption {
+	return func(f *Fake) {
+		f.log = w
+	}
+}
+
+// NewFake returns an empty fake Jira with the given projects and users.
+func NewFake(opts ...FakeOption) *Fake {
+	f := &Fake{nextID: 10000}
+	for _, opt := range opts {
+		opt(f)
+	}
+	if len(f.users) == 0 {
+		f.addUser(gojira.User{
+			Name:         "gh2jira",
+			DisplayName:  "gh2jira",
+			EmailAddress: "gh2jira@example.com",
+		})
+	}
+	f.router = f.newRouter()
+	return f
+}
+
+// NewFakeHTTPClient returns a client sending every request to the fake,
+// whatever the host, like NewMockedHTTPClient.
+func NewFakeHTTPClient(f *Fake) *http.Client {
+	server := httptest.NewServer(f)
+	c := server.Client()
+	c.Transport = &EnforceHostRoundTripper{
+		Host:                 server.URL,
+		UpstreamRoundTripper: server.Client().Transport,
+	}
+	return c
+}
+
+// ServeHTTP implementation of `http.Handler`
+func (f *Fake) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	if f.authorized(r) {
+		f.router.ServeHTTP(rec, r)
+	} else {
+		writeJiraError(rec, http.StatusUnauthorized,
+			"You are not authenticated. Authentication required to perform this operation.")
+	}
+	if f.log != nil {
+		fmt.Fprintf(f.log, "%s %s %d\n", r.Method, r.URL.RequestURI(), rec.status)
+	}
+}
+
+func (f *Fake) authorized(r *http.Request) bool {
+	if f.token == "" {
+		return true
+	}
+	if _, password, ok := r.BasicAuth(); ok {
+		return password == f.token
+	}
+	return r.Header.Get("Authorization") == "Bearer "+f.token
+}
+
+// statusRecorder remembers the status written for the request log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Issue returns the issue as the REST v2 API would.
+func (f *Fake) Issue(key string) (*gojira.Issue, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	is := f.issue(key)
+	if is == nil {
+		return nil, false
+	}
+	return f.toIssue(is), true
+}
+
+// Issues returns all the issues, in the order they were created.
+func (f *Fake) Issues() []*gojira.Issue {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var issues []*gojira.Issue
+	for _, is := range f.issues {
+		issues = append(issues, f.toIssue(is))
+	}
+	return issues
+}
+
+// Watchers returns the names of the users watching the issue.
+func (f *Fake) Watchers(key string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if is := f.issue(key); is != nil {
+		return append([]string(nil), is.watchers...)
+	}
+	return nil
+}
+
+// RemoteLinks returns the remote links of the issue.
+func (f *Fake) RemoteLinks(key string) []*gojira.RemoteLink {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if is := f.issue(key); is != nil {
+		return append([]*gojira.RemoteLink(nil), is.remoteLinks...)
+	}
+	return nil
+}
+
+// toIssue converts the v2 rendering of the issue the way a client would.
+func (f *Fake) toIssue(is *fakeIssue) *gojira.Issue {
+	issue := &gojira.Issue{}
+	data := MustMarshal(f.render(is, "http://localhost", "2"))
+	if err := json.Unmarshal(data, issue); err != nil {
+		panic(err)
+	}
+	return issue
+}
+
+func (f *Fake) newID() string {
+	f.nextID++
+	return fmt.Sprint(f.nextID)
+}
+
+func (f *Fake) addUser(user gojira.User) {
+	u := user
+	if u.Key == "" {
+		u.Key = u.Name
+	}
+	if u.AccountID == "" {
+		u.AccountID = f.newID()
+	}
+	u.Active = true
+	f.users = append(f.users, &u)
+}
+
+func (f *Fake) addVersion(p *fakeProject, name string) *gojira.Version {
+	id := f.newID()
+	v := &gojira.Version{ID: id, Name: name, ProjectID: atoi(p.id)}
+	p.versions = append(p.versions, v)
+	return v
+}
+
+// myself is the authenticated user.
+func (f *Fake) myself() *gojira.User {
+	return f.users[0]
+}
+
+func (f *Fake) project(keyOrID string) *fakeProject {
+	for _, p := range f.projects {
+		if strings.EqualFold(p.key, keyOrID) || p.id == keyOrID {
+			return p
+		}
+	}
+	return nil
+}
+
+func (f *Fake) issue(keyOrID string) *fakeIssue {
+	for _, is := range f.issues {
+		if strings.EqualFold(is.key, keyOrID) || is.id == keyOrID {
+			return is
+		}
+	}
+	return nil
+}
+
+// user finds a user by name, key, account id or email address.
+func (f *Fake) user(name string) *gojira.User {
+	for _, u := range f.users {
+		if name != "" && (u.Name == name || u.Key == name || u.AccountID == name ||
+			strings.EqualFold(u.EmailAddress, name)) {
+			return u
+		}
+	}
+	return nil
+}
+
+// render returns the issue as the given REST API version writes it.
+func (f *Fake) render(is *fakeIssue, base, version string) map[string]interface{} {
+	fields := map[string]interface{}{}
+	for k, v := range is.fields {
+		fields[k] = v
+	}
+	fields["project"] = map[string]interface{}{
+		"id":   is.project.id,
+		"key":  is.project.key,
+		"name": is.project.name,
+		"self": base + "/rest/api/2/project/" + is.project.id,
+	}
+	if d, ok := fields["description"]; ok {
+		fields["description"] = richText(d, version)
+	}
+	fields["status"] = renderStatus(is.status)
+	fields["created"] = is.created.Format(fakeTimeFormat)
+	fields["updated"] = is.updated.Format(fakeTimeFormat)
+
+	var comments []interface{}
+	for _, c := range is.comments {
+		comments = append(comments, renderComment(c, is, base, version))
+	}
+	fields["comment"] = map[string]interface{}{
+		"comments":   nonNil(comments),
+		"maxResults": len(comments),
+		"total":      len(comments),
+		"startAt":    0,
+	}
+
+	var links []interface{}
+	for _, l := range f.links {
+		switch is {
+		case l.inward:
+			links = append(links, map[string]interface{}{
+				"id": l.id, "type": l.linkType, "outwardIssue": linkedIssue(l.outward, base),
+			})
+		case l.outward:
+			links = append(links, map[string]interface{}{
+				"id": l.id, "type": l.linkType, "inwardIssue": linkedIssue(l.inward, base),
+			})
+		}
+	}
+	fields["issuelinks"] = nonNil(links)
+
+	var attachments []interface{}
+	for _, a := range f.attachments {
+		if a.issue == is {
+			attachments = append(attachments, renderAttachment(a, base))
+		}
+	}
+	fields["attachment"] = nonNil(attachments)
+	fields["watches"] = map[string]interface{}{
+		"watchCount": len(is.watchers),
+		"isWatching": contains(is.watchers, f.myself().Name),
+	}
+
+	return map[string]interface{}{
+		"id":     is.id,
+		"key":    is.key,
+		"self":   base + "/rest/api/" + version + "/issue/" + is.id,
+		"fields": fields,
+	}
+}
+
+// linkedIssue is the short form of an issue in the links of another.
+func linkedIssue(is *fakeIssue, base string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":   is.id,
+		"key":  is.key,
+		"self": base + "/rest/api/2/issue/" + is.id,
+		"fields": map[string]interface{}{
+			"summary": is.fields["summary"],
+			"status":  renderStatus(is.status),
+		},
+	}
+}
+
+func renderStatus(i int) map[string]interface{} {
+	s := fakeStatuses[i]
+	return map[string]interface{}{
+		"id":   s.id,
+		"name": s.name,
+		"statusCategory": map[string]interface{}{
+			"key":  s.category,
+			"name": s.name,
+		},
+	}
+}
+
+func renderComment(c *fakeComment, is *fakeIssue, base, version string) map[string]interface{} {
+	created := c.created.Format(fakeTimeFormat)
+	return map[string]interface{}{
+		"id":      c.id,
+		"self":    base + "/rest/api/" + version + "/issue/" + is.id + "/comment/" + c.id,
+		"author":  c.author,
+		"body":    richText(c.body, version),
+		"created": created,
+		"updated": created,
+	}
+}
+
+func renderAttachment(a *fakeAttachment, base string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":       a.id,
+		"self":     base + "/rest/api/2/attachment/" + a.id,
+		"filename": a.filename,
+		"author":   a.author,
+		"created":  a.created.Format(fakeTimeFormat),
+		"size":     len(a.content),
+		"mimeType": a.mimeType,
+		"content":  base + "/secure/attachment/" + a.id + "/" + a.filename,
+	}
+}
+
+// richText converts a description or comment body to the format of the API
+// version: plain text for v2, an Atlassian Document Format document for v3.
+func richText(v interface{}, version string) interface{} {
+	s, isText := v.(string)
+	switch {
+	case version == "2" && !isText:
+		return plainText(v)
+	case version == "3" && isText:
+		doc := map[string]interface{}{"type": "doc", "version": 1, "content": []interface{}{}}
+		if s != "" {
+			doc["content"] = []interface{}{map[string]interface{}{
+				"type":    "paragraph",
+				"content": []interface{}{map[string]interface{}{"type": "text", "text": s}},
+			}}
+		}
+		return doc
+	}
+	return v
+}
+
+// plainText returns the text of a description or comment, an ADF document
+// for the v3 API.
+func plainText(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	var b strings.Builder
+	var walk func(n interface{})
+	walk = func(n interface{}) {
+		node, ok := n.(map[string]interface{})
+		if !ok {
+			return
+		}
+		switch node["type"] {
+		case "text":
+			s, _ := node["text"].(string)
+			b.WriteString(s)
+		case "hardBreak":
+			b.WriteString("\n")
+		}
+		children, _ := node["content"].([]interface{})
+		for _, c := range children {
+			walk(c)
+		}
+		switch node["type"] {
+		case "paragraph", "heading", "codeBlock", "listItem", "blockquote":
+			b.WriteString("\n")
+		}
+	}
+	walk(v)
+	return strings.TrimSpace(b.String())
+}
+
+func nonNil(v []interface{}) []interface{} {
+	if v == nil {
+		return []interface{}{}
+	}
+	return v
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func atoi(s string) int {
+	var i int
+	fmt.Sscan(s, &i)
+	return i
+}
+
+// sortedIssues returns the issues ordered by the given fields, ORDER BY in
+// JQL, or in creation order.
+func sortedIssues(issues []*fakeIssue, order []jqlOrder) []*fakeIssue {
+	sorted := append([]*fakeIssue(nil), issues...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		for _, o := range order {
+			a, b := sorted[i], sorted[j]
+			if o.desc {
+				a, b = b, a
+			}
+			switch o.field {
+			case "created":
+				if !a.created.Equal(b.created) {
+					return a.created.Before(b.created)
+				}
+			case "updated":
+				if !a.updated.Equal(b.updated) {
+					return a.updated.Before(b.updated)
+				}
+			case "key":
+				if atoi(a.id) != atoi(b.id) {
+					return atoi(a.id) < atoi(b.id)
+				}
+			}
+		}
+		// ties in creation order, whatever the direction
+		return atoi(sorted[i].id) < atoi(sorted[j].id)
+	})
+	return sorted
+}
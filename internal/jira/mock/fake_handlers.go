@@ -0,0 +1,842 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	gojira "github.com/andygrunwald/go-jira"
+	"github.com/gorilla/mux"
+)
+
+// maxFakeResults caps maxResults of a search, like Jira does.
+const maxFakeResults = 1000
+
+func (f *Fake) newRouter() *mux.Router {
+	router := mux.NewRouter()
+	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJiraError(w, http.StatusNotFound,
+			fmt.Sprintf("fake jira does not support %s %s", r.Method, r.URL.Path))
+	})
+
+	api := router.PathPrefix("/rest/api/{version:[23]}").Subrouter()
+	handle := func(path string, h func(http.ResponseWriter, *http.Request), methods ...string) {
+		api.HandleFunc(path, f.locked(h)).Methods(methods...)
+	}
+	handle("/myself", f.getMyself, "GET")
+	handle("/mypermissions", f.getMyPermissions, "GET")
+	handle("/user", f.getUser, "GET")
+	handle("/user/search", f.searchUsers, "GET")
+	handle("/project", f.getProjects, "GET")
+	handle("/project/{project}", f.getProject, "GET")
+	handle("/project/{project}/versions", f.getVersions, "GET")
+	handle("/version", f.createVersion, "POST")
+	handle("/issueLinkType", f.getLinkTypes, "GET")
+	handle("/issueLink", f.createLink, "POST")
+	handle("/search", f.search, "GET", "POST")
+	handle("/attachment/{id}", f.getAttachment, "GET")
+	handle("/attachment/{id}", f.deleteAttachment, "DELETE")
+	handle("/issue", f.createIssue, "POST")
+	handle("/issue/createmeta", f.getCreateMeta, "GET")
+	handle("/issue/{issue}", f.getIssue, "GET")
+	handle("/issue/{issue}", f.updateIssue, "PUT")
+	handle("/issue/{issue}/comment", f.getComments, "GET")
+	handle("/issue/{issue}/comment", f.addComment, "POST")
+	handle("/issue/{issue}/remotelink", f.getRemoteLinks, "GET")
+	handle("/issue/{issue}/remotelink", f.addRemoteLink, "POST")
+	handle("/issue/{issue}/watchers", f.getWatchers, "GET")
+	handle("/issue/{issue}/watchers", f.addWatcher, "POST")
+	handle("/issue/{issue}/transitions", f.getTransitions, "GET")
+	handle("/issue/{issue}/transitions", f.doTransition, "POST")
+	handle("/issue/{issue}/attachments", f.addAttachments, "POST")
+	router.HandleFunc("/secure/attachment/{id}/{filename:.*}",
+		f.locked(f.getAttachmentContent)).Methods("GET")
+	return router
+}
+
+// locked serializes the requests, the state is shared.
+func (f *Fake) locked(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		h(w, r)
+	}
+}
+
+func (f *Fake) getMyself(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, f.myself())
+}
+
+// getMyPermissions grants every permission asked for in an existing project.
+func (f *Fake) getMyPermissions(w http.ResponseWriter, r *http.Request) {
+	if key := r.URL.Query().Get("projectKey"); key != "" && f.project(key) == nil {
+		writeJiraError(w, http.StatusNotFound, fmt.Sprintf("No project could be found with key '%s'.", key))
+		return
+	}
+	perms := map[string]interface{}{}
+	for _, p := range strings.Split(r.URL.Query().Get("permissions"), ",") {
+		if p != "" {
+			perms[p] = map[string]interface{}{"key": p, "havePermission": true}
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"permissions": perms})
+}
+
+func (f *Fake) getUser(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	for _, param := range []string{"username", "key", "accountId"} {
+		if name := q.Get(param); name != "" {
+			if u := f.user(name); u != nil {
+				writeJSON(w, http.StatusOK, u)
+				return
+			}
+			writeJiraError(w, http.StatusNotFound, fmt.Sprintf("The user named '%s' does not exist", name))
+			return
+		}
+	}
+	writeJiraError(w, http.StatusBadRequest, "The username, key or accountId query parameter is required")
+}
+
+func (f *Fake) searchUsers(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("username")
+	if q == "" {
+		q = r.URL.Query().Get("query")
+	}
+	q = strings.ToLower(q)
+	users := []*gojira.User{}
+	for _, u := range f.users {
+		if strings.HasPrefix(strings.ToLower(u.Name), q) ||
+			strings.HasPrefix(strings.ToLower(u.DisplayName), q) ||
+			strings.HasPrefix(strings.ToLower(u.EmailAddress), q) {
+			users = append(users, u)
+		}
+	}
+	writeJSON(w, http.StatusOK, users)
+}
+
+func (f *Fake) getProjects(w http.ResponseWriter, r *http.Request) {
+	projects := []interface{}{}
+	for _, p := range f.projects {
+		projects = append(projects, renderProject(p, baseURL(r)))
+	}
+	writeJSON(w, http.StatusOK, projects)
+}
+
+func (f *Fake) getProject(w http.ResponseWriter, r *http.Request) {
+	p := f.requireProject(w, mux.Vars(r)["project"])
+	if p == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, renderProject(p, baseURL(r)))
+}
+
+func (f *Fake) getVersions(w http.ResponseWriter, r *http.Request) {
+	p := f.requireProject(w, mux.Vars(r)["project"])
+	if p == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, append([]*gojira.Version{}, p.versions...))
+}
+
+func (f *Fake) createVersion(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name      string `json:"name"`
+		Project   string `json:"project"`
+		ProjectID int    `json:"projectId"`
+	}
+	if !decode(w, r, &req) {
+		return
+	}
+	project := req.Project
+	if project == "" {
+		project = strconv.Itoa(req.ProjectID)
+	}
+	p := f.project(project)
+	switch {
+	case p == nil:
+		writeFieldError(w, "project", "Project must be specified to create a version.")
+		return
+	case req.Name == "":
+		writeFieldError(w, "name", "You must specify a valid version name")
+		return
+	}
+	for _, v := range p.versions {
+		if strings.EqualFold(v.Name, req.Name) {
+			writeFieldError(w, "name", "A version with this name already exists in this project.")
+			return
+		}
+	}
+	writeJSON(w, http.StatusCreated, f.addVersion(p, req.Name))
+}
+
+func (f *Fake) getLinkTypes(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"issueLinkTypes": fakeLinkTypes})
+}
+
+func (f *Fake) createLink(w http.ResponseWriter, r *http.Request) {
+	var req gojira.IssueLink
+	if !decode(w, r, &req) {
+		return
+	}
+	var linkType *gojira.IssueLinkType
+	for i, t := range fakeLinkTypes {
+		if strings.EqualFold(t.Name, req.Type.Name) || t.ID == req.Type.ID {
+			linkType = &fakeLinkTypes[i]
+		}
+	}
+	if linkType == nil {
+		writeJiraError(w, http.StatusNotFound,
+			fmt.Sprintf("No issue link type with name '%s' found.", req.Type.Name))
+		return
+	}
+	if req.InwardIssue == nil || req.OutwardIssue == nil {
+		writeJiraError(w, http.StatusBadRequest, "Both the inward and the outward issue are required.")
+		return
+	}
+	inward := f.requireIssue(w, req.InwardIssue.Key)
+	if inward == nil {
+		return
+	}
+	outward := f.requireIssue(w, req.OutwardIssue.Key)
+	if outward == nil {
+		return
+	}
+	f.links = append(f.links, &fakeLink{
+		id:       f.newID(),
+		linkType: *linkType,
+		inward:   inward,
+		outward:  outward,
+	})
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (f *Fake) search(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		JQL           string `json:"jql"`
+		StartAt       int    `json:"startAt"`
+		MaxResults    int    `json:"maxResults"`
+		ValidateQuery string `json:"validateQuery"`
+	}
+	if r.Method == http.MethodPost {
+		if !decode(w, r, &req) {
+			return
+		}
+	} else {
+		q := r.URL.Query()
+		req.JQL = q.Get("jql")
+		req.StartAt, _ = strconv.Atoi(q.Get("startAt"))
+		req.MaxResults, _ = strconv.Atoi(q.Get("maxResults"))
+		req.ValidateQuery = q.Get("validateQuery")
+	}
+	if req.MaxResults <= 0 || req.MaxResults > maxFakeResults {
+		req.MaxResults = 50
+	}
+
+	query, err := parseJQL(req.JQL)
+	if err != nil {
+		writeJiraError(w, http.StatusBadRequest, "Error in the JQL Query: "+err.Error())
+		return
+	}
+	// jira fails on missing keys unless told to only warn about them
+	var warnings []string
+	for _, c := range query.clauses {
+		if c.field != "key" || (c.op != "=" && c.op != "in") {
+			continue
+		}
+		for _, key := range c.values {
+			if f.issue(key) == nil {
+				msg := fmt.Sprintf("An issue with key '%s' does not exist for field 'key'.", key)
+				if req.ValidateQuery != "warn" && req.ValidateQuery != "false" {
+					writeJiraError(w, http.StatusBadRequest, msg)
+					return
+				}
+				warnings = append(warnings, msg)
+			}
+		}
+	}
+
+	var matched []*fakeIssue
+	for _, is := range sortedIssues(f.issues, query.order) {
+		if query.match(is) {
+			matched = append(matched, is)
+		}
+	}
+	issues := []interface{}{}
+	for i := req.StartAt; i < len(matched) && len(issues) < req.MaxResults; i++ {
+		issues = append(issues, f.render(matched[i], baseURL(r), mux.Vars(r)["version"]))
+	}
+	resp := map[string]interface{}{
+		"startAt":    req.StartAt,
+		"maxResults": req.MaxResults,
+		"total":      len(matched),
+		"issues":     issues,
+	}
+	if len(warnings) > 0 {
+		resp["warningMessages"] = warnings
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (f *Fake) createIssue(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Fields map[string]interface{} `json:"fields"`
+	}
+	if !decode(w, r, &req) {
+		return
+	}
+	fields := req.Fields
+	if fields == nil {
+		fields = map[string]interface{}{}
+	}
+
+	ref, _ := fields["project"].(map[string]interface{})
+	p := f.project(refName(ref, "key"))
+	if p == nil {
+		writeFieldError(w, "project", "project is required")
+		return
+	}
+	issueType, ok := projectIssueType(p, fields["issuetype"])
+	if !ok {
+		writeFieldError(w, "issuetype", "valid issue type is required")
+		return
+	}
+	if s, _ := fields["summary"].(string); strings.TrimSpace(s) == "" {
+		writeFieldError(w, "summary", "You must specify a summary of the issue.")
+		return
+	}
+	for _, computed := range []string{"status", "issuelinks", "comment", "attachment",
+		"created", "updated", "watches"} {
+		delete(fields, computed)
+	}
+	fields["issuetype"] = issueType
+
+	p.counter++
+	now := time.Now()
+	is := &fakeIssue{
+		id:      f.newID(),
+		key:     fmt.Sprintf("%s-%d", p.key, p.counter),
+		project: p,
+		fields:  fields,
+		created: now,
+		updated: now,
+	}
+	f.issues = append(f.issues, is)
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"id":   is.id,
+		"key":  is.key,
+		"self": baseURL(r) + "/rest/api/" + mux.Vars(r)["version"] + "/issue/" + is.id,
+	})
+}
+
+// getCreateMeta describes the projects and issue types issues can be created
+// in, and their fields.
+func (f *Fake) getCreateMeta(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	keys := splitList(q.Get("projectKeys"))
+	types := splitList(q.Get("issuetypeNames"))
+	projects := []interface{}{}
+	for _, p := range f.projects {
+		if len(keys) > 0 && !containsFold(keys, p.key) {
+			continue
+		}
+		issueTypes := []interface{}{}
+		for i, t := range p.issueTypes {
+			if len(types) > 0 && !containsFold(types, t) {
+				continue
+			}
+			issueTypes = append(issueTypes, map[string]interface{}{
+				"id":     issueTypeID(i),
+				"name":   t,
+				"fields": createMetaFields(p),
+			})
+		}
+		project := renderProject(p, baseURL(r))
+		project["issuetypes"] = issueTypes
+		projects = append(projects, project)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"projects": projects})
+}
+
+func createMetaFields(p *fakeProject) map[string]interface{} {
+	field := func(name, schemaType string, required bool) map[string]interface{} {
+		return map[string]interface{}{
+			"name":     name,
+			"required": required,
+			"schema":   map[string]interface{}{"type": schemaType},
+		}
+	}
+	fields := map[string]interface{}{
+		"summary":     field("Summary", "string", true),
+		"issuetype":   field("Issue Type", "issuetype", true),
+		"project":     field("Project", "project", true),
+		"description": field("Description", "string", false),
+		"labels":      field("Labels", "array", false),
+		"fixVersions": field("Fix Version/s", "array", false),
+	}
+	fields["fixVersions"].(map[string]interface{})["allowedValues"] = append([]*gojira.Version{}, p.versions...)
+	return fields
+}
+
+func (f *Fake) getIssue(w http.ResponseWriter, r *http.Request) {
+	is := f.requireIssue(w, mux.Vars(r)["issue"])
+	if is == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, f.render(is, baseURL(r), mux.Vars(r)["version"]))
+}
+
+// updateIssue sets the fields given and applies the set, add and remove
+// operations of update, e.g. {"update": {"labels": [{"add": "triaged"}]}}.
+func (f *Fake) updateIssue(w http.ResponseWriter, r *http.Request) {
+	is := f.requireIssue(w, mux.Vars(r)["issue"])
+	if is == nil {
+		return
+	}
+	var req struct {
+		Fields map[string]interface{}              `json:"fields"`
+		Update map[string][]map[string]interface{} `json:"update"`
+	}
+	if !decode(w, r, &req) {
+		return
+	}
+	for name, v := range req.Fields {
+		switch name {
+		case "project", "status", "created", "updated", "issuelinks", "comment", "attachment", "watches":
+			writeFieldError(w, name, fmt.Sprintf("Field '%s' cannot be set. It is not on the appropriate screen, or unknown.", name))
+			return
+		case "issuetype":
+			t, ok := projectIssueType(is.project, v)
+			if !ok {
+				writeFieldError(w, name, "valid issue type is required")
+				return
+			}
+			is.fields[name] = t
+		default:
+			is.fields[name] = v
+		}
+	}
+	for name, ops := range req.Update {
+		for _, op := range ops {
+			for verb, v := range op {
+				list, _ := is.fields[name].([]interface{})
+				switch verb {
+				case "set":
+					is.fields[name] = v
+				case "add":
+					is.fields[name] = append(list, v)
+				case "remove":
+					var kept []interface{}
+					for _, have := range list {
+						if string(MustMarshal(have)) != string(MustMarshal(v)) {
+							kept = append(kept, have)
+						}
+					}
+					is.fields[name] = nonNil(kept)
+				default:
+					writeFieldError(w, name, fmt.Sprintf("unsupported update operation %q", verb))
+					return
+				}
+			}
+		}
+	}
+	is.updated = time.Now()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *Fake) getComments(w http.ResponseWriter, r *http.Request) {
+	is := f.requireIssue(w, mux.Vars(r)["issue"])
+	if is == nil {
+		return
+	}
+	comments := []interface{}{}
+	for _, c := range is.comments {
+		comments = append(comments, renderComment(c, is, baseURL(r), mux.Vars(r)["version"]))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"comments":   comments,
+		"startAt":    0,
+		"maxResults": len(comments),
+		"total":      len(comments),
+	})
+}
+
+func (f *Fake) addComment(w http.ResponseWriter, r *http.Request) {
+	is := f.requireIssue(w, mux.Vars(r)["issue"])
+	if is == nil {
+		return
+	}
+	var req struct {
+		Body interface{} `json:"body"`
+	}
+	if !decode(w, r, &req) {
+		return
+	}
+	if strings.TrimSpace(plainText(req.Body)) == "" {
+		writeFieldError(w, "comment", "Comment body can not be empty!")
+		return
+	}
+	c := &fakeComment{
+		id:      f.newID(),
+		author:  f.myself(),
+		body:    req.Body,
+		created: time.Now(),
+	}
+	is.comments = append(is.comments, c)
+	is.updated = c.created
+	writeJSON(w, http.StatusCreated, renderComment(c, is, baseURL(r), mux.Vars(r)["version"]))
+}
+
+func (f *Fake) getRemoteLinks(w http.ResponseWriter, r *http.Request) {
+	is := f.requireIssue(w, mux.Vars(r)["issue"])
+	if is == nil {
+		return
+	}
+	writeJSON(w, http.StatusOK, append([]*gojira.RemoteLink{}, is.remoteLinks...))
+}
+
+// addRemoteLink adds the link, or updates the one with the same global id.
+func (f *Fake) addRemoteLink(w http.ResponseWriter, r *http.Request) {
+	is := f.requireIssue(w, mux.Vars(r)["issue"])
+	if is == nil {
+		return
+	}
+	var link gojira.RemoteLink
+	if !decode(w, r, &link) {
+		return
+	}
+	if link.Object == nil || link.Object.URL == "" || link.Object.Title == "" {
+		writeFieldError(w, "object", "The url and title of the object are required.")
+		return
+	}
+	status := http.StatusCreated
+	if link.GlobalID != "" {
+		for i, have := range is.remoteLinks {
+			if have.GlobalID == link.GlobalID {
+				link.ID = have.ID
+				is.remoteLinks[i] = &link
+				status = http.StatusOK
+			}
+		}
+	}
+	if link.ID == 0 {
+		link.ID = atoi(f.newID())
+		is.remoteLinks = append(is.remoteLinks, &link)
+	}
+	link.Self = fmt.Sprintf("%s/rest/api/2/issue/%s/remotelink/%d", baseURL(r), is.key, link.ID)
+	writeJSON(w, status, map[string]interface{}{"id": link.ID, "self": link.Self})
+}
+
+func (f *Fake) getWatchers(w http.ResponseWriter, r *http.Request) {
+	is := f.requireIssue(w, mux.Vars(r)["issue"])
+	if is == nil {
+		return
+	}
+	watchers := []*gojira.User{}
+	for _, name := range is.watchers {
+		watchers = append(watchers, f.user(name))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"watchCount": len(watchers),
+		"isWatching": contains(is.watchers, f.myself().Name),
+		"watchers":   watchers,
+	})
+}
+
+// addWatcher adds the user named in the body, a JSON string, or the current
+// user without one.
+func (f *Fake) addWatcher(w http.ResponseWriter, r *http.Request) {
+	is := f.requireIssue(w, mux.Vars(r)["issue"])
+	if is == nil {
+		return
+	}
+	name := f.myself().Name
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeJiraError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(strings.TrimSpace(string(data))) > 0 {
+		if err := json.Unmarshal(data, &name); err != nil {
+			writeJiraError(w, http.StatusBadRequest, "The body must be the name of the user: "+err.Error())
+			return
+		}
+	}
+	u := f.user(name)
+	if u == nil {
+		writeJiraError(w, http.StatusNotFound, fmt.Sprintf("The user \"%s\" does not exist", name))
+		return
+	}
+	if !contains(is.watchers, u.Name) {
+		is.watchers = append(is.watchers, u.Name)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getTransitions offers a transition to every other status.
+func (f *Fake) getTransitions(w http.ResponseWriter, r *http.Request) {
+	is := f.requireIssue(w, mux.Vars(r)["issue"])
+	if is == nil {
+		return
+	}
+	transitions := []interface{}{}
+	for i, s := range fakeStatuses {
+		if i == is.status {
+			continue
+		}
+		transitions = append(transitions, map[string]interface{}{
+			"id":   s.transition,
+			"name": s.name,
+			"to":   renderStatus(i),
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"transitions": transitions})
+}
+
+func (f *Fake) doTransition(w http.ResponseWriter, r *http.Request) {
+	is := f.requireIssue(w, mux.Vars(r)["issue"])
+	if is == nil {
+		return
+	}
+	var req struct {
+		Transition struct {
+			ID string `json:"id"`
+		} `json:"transition"`
+	}
+	if !decode(w, r, &req) {
+		return
+	}
+	for i, s := range fakeStatuses {
+		if s.transition == req.Transition.ID && i != is.status {
+			is.status = i
+			is.updated = time.Now()
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeFieldError(w, "transition",
+		fmt.Sprintf("Transition id '%s' is not valid for this issue.", req.Transition.ID))
+}
+
+// addAttachments stores the files of the multipart form, which Jira only
+// accepts with the X-Atlassian-Token: no-check, or nocheck, header.
+func (f *Fake) addAttachments(w http.ResponseWriter, r *http.Request) {
+	is := f.requireIssue(w, mux.Vars(r)["issue"])
+	if is == nil {
+		return
+	}
+	if t := r.Header.Get("X-Atlassian-Token"); t != "no-check" && t != "nocheck" {
+		writeJiraError(w, http.StatusForbidden, "XSRF check failed")
+		return
+	}
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		writeJiraError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	created := []interface{}{}
+	for _, fh := range r.MultipartForm.File["file"] {
+		file, err := fh.Open()
+		if err != nil {
+			writeJiraError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		content, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			writeJiraError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		mimeType := fh.Header.Get("Content-Type")
+		if t, _, err := mime.ParseMediaType(mimeType); err == nil {
+			mimeType = t
+		} else {
+			mimeType = http.DetectContentType(content)
+		}
+		a := &fakeAttachment{
+			id:       f.newID(),
+			issue:    is,
+			filename: fh.Filename,
+			mimeType: mimeType,
+			author:   f.myself(),
+			created:  time.Now(),
+			content:  content,
+		}
+		f.attachments = append(f.attachments, a)
+		created = append(created, renderAttachment(a, baseURL(r)))
+	}
+	if len(created) == 0 {
+		writeJiraError(w, http.StatusBadRequest, "No file named file in the multipart form.")
+		return
+	}
+	writeJSON(w, http.StatusOK, created)
+}
+
+func (f *Fake) getAttachment(w http.ResponseWriter, r *http.Request) {
+	if a := f.requireAttachment(w, mux.Vars(r)["id"]); a != nil {
+		writeJSON(w, http.StatusOK, renderAttachment(a, baseURL(r)))
+	}
+}
+
+func (f *Fake) deleteAttachment(w http.ResponseWriter, r *http.Request) {
+	a := f.requireAttachment(w, mux.Vars(r)["id"])
+	if a == nil {
+		return
+	}
+	for i, have := range f.attachments {
+		if have == a {
+			f.attachments = append(f.attachments[:i], f.attachments[i+1:]...)
+			break
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *Fake) getAttachmentContent(w http.ResponseWriter, r *http.Request) {
+	a := f.requireAttachment(w, mux.Vars(r)["id"])
+	if a == nil {
+		return
+	}
+	w.Header().Set("Content-Type", a.mimeType)
+	w.Write(a.content)
+}
+
+func (f *Fake) requireProject(w http.ResponseWriter, keyOrID string) *fakeProject {
+	p := f.project(keyOrID)
+	if p == nil {
+		writeJiraError(w, http.StatusNotFound,
+			fmt.Sprintf("No project could be found with key '%s'.", keyOrID))
+	}
+	return p
+}
+
+func (f *Fake) requireIssue(w http.ResponseWriter, keyOrID string) *fakeIssue {
+	is := f.issue(keyOrID)
+	if is == nil {
+		writeJiraError(w, http.StatusNotFound, "Issue Does Not Exist")
+	}
+	return is
+}
+
+func (f *Fake) requireAttachment(w http.ResponseWriter, id string) *fakeAttachment {
+	for _, a := range f.attachments {
+		if a.id == id {
+			return a
+		}
+	}
+	writeJiraError(w, http.StatusNotFound, fmt.Sprintf("The attachment with id '%s' does not exist", id))
+	return nil
+}
+
+// projectIssueType finds the issue type, given by name or id, among those of
+// the project.
+func projectIssueType(p *fakeProject, v interface{}) (map[string]interface{}, bool) {
+	ref, _ := v.(map[string]interface{})
+	for i, t := range p.issueTypes {
+		if strings.EqualFold(t, refName(ref, "name")) || issueTypeID(i) == refName(ref, "id") {
+			return map[string]interface{}{"id": issueTypeID(i), "name": t}, true
+		}
+	}
+	return nil, false
+}
+
+func issueTypeID(i int) string {
+	return strconv.Itoa(i + 1)
+}
+
+// refName returns the field of a reference to a project, issue type etc.,
+// falling back to the id.
+func refName(ref map[string]interface{}, field string) string {
+	if s, ok := ref[field].(string); ok && s != "" {
+		return s
+	}
+	if id, ok := ref["id"]; ok {
+		return fmt.Sprint(id)
+	}
+	return ""
+}
+
+func renderProject(p *fakeProject, base string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":   p.id,
+		"key":  p.key,
+		"name": p.name,
+		"self": base + "/rest/api/2/project/" + p.id,
+	}
+}
+
+// baseURL is the URL of the fake as the client sees it.
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// decode reads the JSON body into v, answering 400 if it cannot.
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeJiraError(w, http.StatusBadRequest, "Unable to parse the request body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(MustMarshal(v))
+}
+
+// writeJiraError writes an error the way Jira does, which go-jira reports.
+func writeJiraError(w http.ResponseWriter, status int, messages ...string) {
+	writeJSON(w, status, map[string]interface{}{
+		"errorMessages": messages,
+		"errors":        map[string]string{},
+	})
+}
+
+// writeFieldError writes a 400 error about a field of the request.
+func writeFieldError(w http.ResponseWriter, field, message string) {
+	writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+		"errorMessages": []string{},
+		"errors":        map[string]string{field: message},
+	})
+}
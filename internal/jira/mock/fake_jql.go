@@ -0,0 +1,322 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// jqlQuery is a parsed JQL query. The fake understands clauses joined with
+// AND, the operators =, !=, ~, !~, in and not in, and ORDER BY, enough for
+// the queries gh2jira writes, e.g.
+//
+//	key in (OSDK-1,OSDK-2)
+//	description ~ "\"https://github.com/foo/bar/issues/1\""
+//	project = OSDK AND status != Done ORDER BY created DESC
+type jqlQuery struct {
+	clauses []jqlClause
+	order   []jqlOrder
+}
+
+type jqlClause struct {
+	field  string
+	op     string
+	values []string
+}
+
+type jqlOrder struct {
+	field string
+	desc  bool
+}
+
+// jqlFields are the fields the fake can search on.
+var jqlFields = map[string]string{
+	"key":         "key",
+	"issuekey":    "key",
+	"id":          "key",
+	"project":     "project",
+	"status":      "status",
+	"summary":     "summary",
+	"description": "description",
+	"text":        "text",
+	"labels":      "labels",
+	"type":        "issuetype",
+	"issuetype":   "issuetype",
+}
+
+// jqlOrderFields are the fields the fake can sort on.
+var jqlOrderFields = map[string]string{
+	"key":      "key",
+	"issuekey": "key",
+	"created":  "created",
+	"updated":  "updated",
+}
+
+type jqlToken struct {
+	text   string
+	quoted bool
+}
+
+// parseJQL parses the query, failing on anything the fake does not support.
+func parseJQL(jql string) (*jqlQuery, error) {
+	tokens, err := tokenizeJQL(jql)
+	if err != nil {
+		return nil, err
+	}
+	q := &jqlQuery{}
+	p := &jqlParser{tokens: tokens}
+	for !p.done() && !p.keyword("order") {
+		if len(q.clauses) > 0 {
+			if !p.keyword("and") {
+				return nil, fmt.Errorf("unsupported JQL at %q, only AND is supported", p.peek().text)
+			}
+			p.next()
+		}
+		c, err := p.clause()
+		if err != nil {
+			return nil, err
+		}
+		q.clauses = append(q.clauses, c)
+	}
+	if p.keyword("order") {
+		p.next()
+		if !p.keyword("by") {
+			return nil, fmt.Errorf("expecting BY after ORDER")
+		}
+		p.next()
+		for {
+			name := strings.ToLower(p.next().text)
+			field, ok := jqlOrderFields[name]
+			if !ok {
+				return nil, fmt.Errorf("cannot order by %q", name)
+			}
+			o := jqlOrder{field: field}
+			if p.keyword("asc") || p.keyword("desc") {
+				o.desc = strings.EqualFold(p.next().text, "desc")
+			}
+			q.order = append(q.order, o)
+			if p.done() || p.peek().text != "," {
+				break
+			}
+			p.next()
+		}
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("unexpected %q at the end of the query", p.peek().text)
+	}
+	return q, nil
+}
+
+type jqlParser struct {
+	tokens []jqlToken
+	pos    int
+}
+
+func (p *jqlParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *jqlParser) peek() jqlToken {
+	if p.done() {
+		return jqlToken{}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *jqlParser) next() jqlToken {
+	t := p.peek()
+	p.pos++
+	return t
+}
+
+// keyword tells whether the next token is the unquoted keyword.
+func (p *jqlParser) keyword(k string) bool {
+	t := p.peek()
+	return !t.quoted && strings.EqualFold(t.text, k)
+}
+
+func (p *jqlParser) clause() (jqlClause, error) {
+	name := p.next()
+	field, ok := jqlFields[strings.ToLower(name.text)]
+	if !ok {
+		return jqlClause{}, fmt.Errorf(
+			"Field '%s' does not exist or you do not have permission to view it.", name.text)
+	}
+	c := jqlClause{field: field}
+
+	switch op := p.next(); {
+	case p.pos > len(p.tokens):
+		return c, fmt.Errorf("expecting an operator after %q", name.text)
+	case op.text == "=" || op.text == "!=" || op.text == "~" || op.text == "!~":
+		c.op = op.text
+	case strings.EqualFold(op.text, "in"):
+		c.op = "in"
+	case strings.EqualFold(op.text, "not") && p.keyword("in"):
+		p.next()
+		c.op = "not in"
+	default:
+		return c, fmt.Errorf("unsupported operator %q", op.text)
+	}
+
+	if c.op != "in" && c.op != "not in" {
+		if p.done() {
+			return c, fmt.Errorf("expecting a value after %s %s", name.text, c.op)
+		}
+		c.values = []string{p.next().text}
+		return c, nil
+	}
+
+	if p.next().text != "(" {
+		return c, fmt.Errorf("expecting ( after %s", c.op)
+	}
+	for {
+		if p.done() {
+			return c, fmt.Errorf("expecting ) to close the list")
+		}
+		c.values = append(c.values, p.next().text)
+		switch sep := p.next(); sep.text {
+		case ",":
+		case ")":
+			return c, nil
+		default:
+			return c, fmt.Errorf("expecting , or ) in the list, got %q", sep.text)
+		}
+	}
+}
+
+// tokenizeJQL splits the query into words, quoted strings, operators and
+// punctuation.
+func tokenizeJQL(jql string) ([]jqlToken, error) {
+	var tokens []jqlToken
+	r := []rune(jql)
+	for i := 0; i < len(r); {
+		switch c := r[i]; {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(' || c == ')' || c == ',' || c == '=' || c == '~':
+			tokens = append(tokens, jqlToken{text: string(c)})
+			i++
+		case c == '!':
+			if i+1 >= len(r) || (r[i+1] != '=' && r[i+1] != '~') {
+				return nil, fmt.Errorf("unexpected ! at %d", i)
+			}
+			tokens = append(tokens, jqlToken{text: string(r[i : i+2])})
+			i += 2
+		case c == '"' || c == '\'':
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(r) && r[j] != c; j++ {
+				if r[j] == '\\' && j+1 < len(r) {
+					j++
+				}
+				b.WriteRune(r[j])
+			}
+			if j >= len(r) {
+				return nil, fmt.Errorf("unterminated string at %d", i)
+			}
+			tokens = append(tokens, jqlToken{text: b.String(), quoted: true})
+			i = j + 1
+		default:
+			j := i
+			for j < len(r) && !unicode.IsSpace(r[j]) && !strings.ContainsRune("()=,~!\"'", r[j]) {
+				j++
+			}
+			tokens = append(tokens, jqlToken{text: string(r[i:j])})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+// match tells whether the issue matches every clause of the query.
+func (q *jqlQuery) match(is *fakeIssue) bool {
+	for _, c := range q.clauses {
+		if !c.match(is) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c jqlClause) match(is *fakeIssue) bool {
+	switch c.op {
+	case "~":
+		return c.contains(is)
+	case "!~":
+		return !c.contains(is)
+	case "!=", "not in":
+		return !c.equals(is)
+	}
+	return c.equals(is)
+}
+
+// equals tells whether the field equals one of the values.
+func (c jqlClause) equals(is *fakeIssue) bool {
+	for _, v := range c.values {
+		for _, have := range fieldValues(is, c.field) {
+			if strings.EqualFold(have, v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// contains does the text search of ~: a quoted phrase must appear as is,
+// otherwise every word must.
+func (c jqlClause) contains(is *fakeIssue) bool {
+	text := strings.ToLower(strings.Join(fieldValues(is, c.field), "\n"))
+	v := strings.ToLower(c.values[0])
+	if len(v) > 1 && strings.HasPrefix(v, `"`) && strings.HasSuffix(v, `"`) {
+		return strings.Contains(text, strings.Trim(v, `"`))
+	}
+	for _, word := range strings.Fields(v) {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// fieldValues returns the values of the field to compare in a query.
+func fieldValues(is *fakeIssue, field string) []string {
+	switch field {
+	case "key":
+		return []string{is.key, is.id}
+	case "project":
+		return []string{is.project.key, is.project.id, is.project.name}
+	case "status":
+		return []string{fakeStatuses[is.status].name, fakeStatuses[is.status].id}
+	case "issuetype":
+		t, _ := is.fields["issuetype"].(map[string]interface{})
+		return []string{fmt.Sprint(t["name"]), fmt.Sprint(t["id"])}
+	case "labels":
+		var labels []string
+		list, _ := is.fields["labels"].([]interface{})
+		for _, l := range list {
+			labels = append(labels, fmt.Sprint(l))
+		}
+		return labels
+	case "text":
+		values := []string{plainText(is.fields["summary"]), plainText(is.fields["description"])}
+		for _, c := range is.comments {
+			values = append(values, plainText(c.body))
+		}
+		return values
+	}
+	return []string{plainText(is.fields[field])}
+}
@@ -0,0 +1,462 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"time"
+
+	gojira "github.com/andygrunwald/go-jira"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Fake", func() {
+	var (
+		fake   *Fake
+		client *gojira.Client
+	)
+
+	BeforeEach(func() {
+		fake = NewFake(
+			WithFakeProject("OSDK", "Operator SDK"),
+			WithFakeProject("SEC", "Security", "Vulnerability"),
+			WithFakeVersion("OSDK", "v1.0.0"),
+			WithFakeUser(gojira.User{Name: "jdoe", DisplayName: "Jane Doe", EmailAddress: "jdoe@example.com"}),
+			WithFakeUser(gojira.User{Name: "jsmith", DisplayName: "John Smith"}),
+		)
+		var err error
+		client, err = gojira.NewClient(NewFakeHTTPClient(fake), "http://localhost")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	create := func(project, summary, description string) *gojira.Issue {
+		issue, _, err := client.Issue.Create(&gojira.Issue{Fields: &gojira.IssueFields{
+			Project:     gojira.Project{Key: project},
+			Type:        gojira.IssueType{Name: "Story"},
+			Summary:     summary,
+			Description: description,
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		return issue
+	}
+
+	Describe("issues", func() {
+		It("should number the issues of each project", func() {
+			Expect(create("OSDK", "one", "").Key).To(Equal("OSDK-1"))
+			Expect(create("OSDK", "two", "").Key).To(Equal("OSDK-2"))
+			_, _, err := client.Issue.Create(&gojira.Issue{Fields: &gojira.IssueFields{
+				Project: gojira.Project{Key: "SEC"},
+				Type:    gojira.IssueType{Name: "Vulnerability"},
+				Summary: "three",
+			}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fake.Issues()).To(HaveLen(3))
+			Expect(fake.Issues()[2].Key).To(Equal("SEC-1"))
+		})
+		It("should get what was created", func() {
+			created := create("OSDK", "[UPSTREAM] Issue 1 #1", "body")
+			issue, _, err := client.Issue.Get(created.Key, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(issue.ID).To(Equal(created.ID))
+			Expect(issue.Fields.Summary).To(Equal("[UPSTREAM] Issue 1 #1"))
+			Expect(issue.Fields.Description).To(Equal("body"))
+			Expect(issue.Fields.Type.Name).To(Equal("Story"))
+			Expect(issue.Fields.Project.Key).To(Equal("OSDK"))
+			Expect(issue.Fields.Status.Name).To(Equal("To Do"))
+		})
+		It("should reject unknown projects, issue types and missing summaries", func() {
+			_, resp, err := client.Issue.Create(&gojira.Issue{Fields: &gojira.IssueFields{
+				Project: gojira.Project{Key: "NOPE"},
+				Type:    gojira.IssueType{Name: "Story"},
+				Summary: "one",
+			}})
+			Expect(err).To(HaveOccurred())
+			Expect(gojira.NewJiraError(resp, err).Error()).To(ContainSubstring("project is required"))
+
+			_, resp, err = client.Issue.Create(&gojira.Issue{Fields: &gojira.IssueFields{
+				Project: gojira.Project{Key: "SEC"},
+				Type:    gojira.IssueType{Name: "Story"},
+				Summary: "one",
+			}})
+			Expect(err).To(HaveOccurred())
+			Expect(gojira.NewJiraError(resp, err).Error()).To(ContainSubstring("valid issue type is required"))
+
+			_, resp, err = client.Issue.Create(&gojira.Issue{Fields: &gojira.IssueFields{
+				Project: gojira.Project{Key: "OSDK"},
+				Type:    gojira.IssueType{Name: "Story"},
+			}})
+			Expect(err).To(HaveOccurred())
+			Expect(gojira.NewJiraError(resp, err).Error()).To(ContainSubstring("must specify a summary"))
+			Expect(fake.Issues()).To(BeEmpty())
+		})
+		It("should return 404 for missing issues", func() {
+			_, resp, err := client.Issue.Get("OSDK-42", nil)
+			Expect(err).To(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(404))
+			Expect(err.Error()).To(ContainSubstring("Issue Does Not Exist"))
+		})
+		It("should update fields and labels", func() {
+			key := create("OSDK", "one", "").Key
+			_, err := client.Issue.UpdateIssue(key, map[string]interface{}{
+				"fields": map[string]interface{}{"summary": "uno", "labels": []string{"a", "b"}},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = client.Issue.UpdateIssue(key, map[string]interface{}{
+				"update": map[string]interface{}{
+					"labels": []map[string]interface{}{{"add": "c"}, {"remove": "a"}},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			issue, _ := fake.Issue(key)
+			Expect(issue.Fields.Summary).To(Equal("uno"))
+			Expect(issue.Fields.Labels).To(Equal([]string{"b", "c"}))
+		})
+		It("should convert descriptions between the v2 and v3 formats", func() {
+			req, err := client.NewRequest("POST", "rest/api/3/issue", map[string]interface{}{
+				"fields": map[string]interface{}{
+					"project":   map[string]string{"key": "OSDK"},
+					"issuetype": map[string]string{"name": "Story"},
+					"summary":   "one",
+					"description": map[string]interface{}{
+						"type": "doc", "version": 1, "content": []interface{}{
+							map[string]interface{}{"type": "paragraph", "content": []interface{}{
+								map[string]interface{}{"type": "text", "text": "Upstream Github issue: "},
+								map[string]interface{}{"type": "text", "text": "https://github.com/foo/bar/issues/1"},
+							}},
+						},
+					},
+				},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = client.Do(req, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			issue, _ := fake.Issue("OSDK-1")
+			Expect(issue.Fields.Description).To(Equal("Upstream Github issue: https://github.com/foo/bar/issues/1"))
+
+			create("OSDK", "two", "plain")
+			req, err = client.NewRequest("GET", "rest/api/3/issue/OSDK-2", nil)
+			Expect(err).NotTo(HaveOccurred())
+			var v3 struct {
+				Fields struct {
+					Description map[string]interface{} `json:"description"`
+				} `json:"fields"`
+			}
+			_, err = client.Do(req, &v3)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(v3.Fields.Description["type"]).To(Equal("doc"))
+			Expect(plainText(v3.Fields.Description)).To(Equal("plain"))
+		})
+	})
+
+	Describe("search", func() {
+		BeforeEach(func() {
+			create("OSDK", "one", "Upstream Github issue: https://github.com/foo/bar/issues/1")
+			create("OSDK", "two", "Upstream Github issue: https://github.com/foo/bar/issues/12")
+			create("OSDK", "three", "nothing upstream")
+		})
+		keys := func(issues []gojira.Issue) []string {
+			var keys []string
+			for _, i := range issues {
+				keys = append(keys, i.Key)
+			}
+			return keys
+		}
+		It("should find issues by key", func() {
+			issues, _, err := client.Issue.Search("key in (OSDK-3,OSDK-1)", nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(keys(issues)).To(Equal([]string{"OSDK-1", "OSDK-3"}))
+		})
+		It("should fail on missing keys unless told to warn", func() {
+			_, _, err := client.Issue.Search("key in (OSDK-1,OSDK-9)", nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("An issue with key 'OSDK-9' does not exist"))
+
+			issues, _, err := client.Issue.Search("key in (OSDK-1,OSDK-9)",
+				&gojira.SearchOptions{ValidateQuery: "warn"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(keys(issues)).To(Equal([]string{"OSDK-1"}))
+		})
+		It("should search a phrase in the description", func() {
+			issues, _, err := client.Issue.Search(
+				`description ~ "\"https://github.com/foo/bar/issues/1\""`, nil)
+			Expect(err).NotTo(HaveOccurred())
+			// like jira, a phrase also matches a longer word
+			Expect(keys(issues)).To(Equal([]string{"OSDK-1", "OSDK-2"}))
+		})
+		It("should combine clauses, order and page", func() {
+			_, err := client.Issue.DoTransition("OSDK-2", "31")
+			Expect(err).NotTo(HaveOccurred())
+
+			issues, _, err := client.Issue.Search("project = OSDK AND status != Done ORDER BY key DESC", nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(keys(issues)).To(Equal([]string{"OSDK-3", "OSDK-1"}))
+
+			issues, resp, err := client.Issue.Search("project = OSDK ORDER BY created",
+				&gojira.SearchOptions{StartAt: 1, MaxResults: 1})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(keys(issues)).To(Equal([]string{"OSDK-2"}))
+			Expect(resp.Total).To(Equal(3))
+		})
+		It("should reject what it does not support", func() {
+			_, _, err := client.Issue.Search("key = OSDK-1 OR key = OSDK-2", nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("only AND is supported"))
+
+			_, _, err = client.Issue.Search("assignee = jdoe", nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Field 'assignee' does not exist"))
+		})
+	})
+
+	Describe("sortedIssues", func() {
+		It("should keep ties in creation order", func() {
+			t := time.Date(2022, 9, 1, 0, 0, 0, 0, time.UTC)
+			issues := []*fakeIssue{
+				{id: "1", created: t, updated: t.Add(time.Hour)},
+				{id: "2", created: t, updated: t},
+				{id: "3", created: t.Add(time.Hour), updated: t},
+			}
+			ids := func(sorted []*fakeIssue) []string {
+				var ids []string
+				for _, is := range sorted {
+					ids = append(ids, is.id)
+				}
+				return ids
+			}
+			Expect(ids(sortedIssues(issues, []jqlOrder{{field: "created", desc: true}}))).
+				To(Equal([]string{"3", "1", "2"}))
+			Expect(ids(sortedIssues(issues, []jqlOrder{{field: "updated", desc: true}, {field: "created"}}))).
+				To(Equal([]string{"1", "2", "3"}))
+			Expect(ids(sortedIssues(issues, []jqlOrder{{field: "updated"}, {field: "key", desc: true}}))).
+				To(Equal([]string{"3", "2", "1"}))
+		})
+	})
+
+	Describe("parseJQL", func() {
+		It("should parse the queries gh2jira writes", func() {
+			q, err := parseJQL(`key in (OSDK-1, "OSDK-2") AND description ~ "\"https://x/1\"" ORDER BY created DESC, key`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(q.clauses).To(Equal([]jqlClause{
+				{field: "key", op: "in", values: []string{"OSDK-1", "OSDK-2"}},
+				{field: "description", op: "~", values: []string{`"https://x/1"`}},
+			}))
+			Expect(q.order).To(Equal([]jqlOrder{{field: "created", desc: true}, {field: "key"}}))
+		})
+		It("should parse not in and negated operators", func() {
+			q, err := parseJQL(`status not in (Done) and summary !~ flaky`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(q.clauses).To(Equal([]jqlClause{
+				{field: "status", op: "not in", values: []string{"Done"}},
+				{field: "summary", op: "!~", values: []string{"flaky"}},
+			}))
+		})
+		It("should reject broken queries", func() {
+			for _, jql := range []string{`key in (OSDK-1`, `key`, `summary ~ "open`, `key = A ORDER created`} {
+				_, err := parseJQL(jql)
+				Expect(err).To(HaveOccurred(), jql)
+			}
+		})
+	})
+
+	Describe("comments, links and watchers", func() {
+		BeforeEach(func() {
+			create("OSDK", "one", "")
+			create("OSDK", "two", "")
+		})
+		It("should add and list comments", func() {
+			c, _, err := client.Issue.AddComment("OSDK-1", &gojira.Comment{Body: "me too"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(c.ID).NotTo(BeEmpty())
+			Expect(c.Author.Name).To(Equal("jdoe"))
+
+			issue, _ := fake.Issue("OSDK-1")
+			Expect(issue.Fields.Comments.Comments).To(HaveLen(1))
+			Expect(issue.Fields.Comments.Comments[0].Body).To(Equal("me too"))
+
+			issues, _, err := client.Issue.Search(`text ~ "too"`, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(issues).To(HaveLen(1))
+		})
+		It("should link issues both ways", func() {
+			_, err := client.Issue.AddLink(&gojira.IssueLink{
+				Type:         gojira.IssueLinkType{Name: "Blocks"},
+				InwardIssue:  &gojira.Issue{Key: "OSDK-2"},
+				OutwardIssue: &gojira.Issue{Key: "OSDK-1"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			one, _ := fake.Issue("OSDK-1")
+			Expect(one.Fields.IssueLinks).To(HaveLen(1))
+			Expect(one.Fields.IssueLinks[0].InwardIssue.Key).To(Equal("OSDK-2"))
+			two, _ := fake.Issue("OSDK-2")
+			Expect(two.Fields.IssueLinks[0].OutwardIssue.Key).To(Equal("OSDK-1"))
+			Expect(two.Fields.IssueLinks[0].Type.Outward).To(Equal("blocks"))
+
+			_, err = client.Issue.AddLink(&gojira.IssueLink{
+				Type:         gojira.IssueLinkType{Name: "Blocks"},
+				InwardIssue:  &gojira.Issue{Key: "OSDK-2"},
+				OutwardIssue: &gojira.Issue{Key: "OSDK-9"},
+			})
+			Expect(err).To(HaveOccurred())
+		})
+		It("should add remote links, updating by global id", func() {
+			link := &gojira.RemoteLink{
+				GlobalID: "github-foo-bar-1",
+				Object:   &gojira.RemoteLinkObject{URL: "https://github.com/foo/bar/issues/1", Title: "foo/bar#1"},
+			}
+			_, _, err := client.Issue.AddRemoteLink("OSDK-1", link)
+			Expect(err).NotTo(HaveOccurred())
+			link.Object.Title = "foo/bar#1 renamed"
+			_, _, err = client.Issue.AddRemoteLink("OSDK-1", link)
+			Expect(err).NotTo(HaveOccurred())
+
+			links, _, err := client.Issue.GetRemoteLinks("OSDK-1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*links).To(HaveLen(1))
+			Expect((*links)[0].Object.Title).To(Equal("foo/bar#1 renamed"))
+			Expect(fake.RemoteLinks("OSDK-1")).To(HaveLen(1))
+		})
+		It("should add watchers that exist", func() {
+			_, err := client.Issue.AddWatcher("OSDK-1", "jsmith")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = client.Issue.AddWatcher("OSDK-1", "nobody")
+			Expect(err).To(HaveOccurred())
+			Expect(fake.Watchers("OSDK-1")).To(Equal([]string{"jsmith"}))
+
+			watchers, _, err := client.Issue.GetWatchers("OSDK-1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect((*watchers)[0].DisplayName).To(Equal("John Smith"))
+		})
+	})
+
+	Describe("transitions", func() {
+		It("should move the issue through the workflow", func() {
+			create("OSDK", "one", "")
+			transitions, _, err := client.Issue.GetTransitions("OSDK-1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(transitions).To(HaveLen(2))
+			Expect(transitions[1].To.Name).To(Equal("Done"))
+
+			_, err = client.Issue.DoTransition("OSDK-1", transitions[1].ID)
+			Expect(err).NotTo(HaveOccurred())
+			issue, _ := fake.Issue("OSDK-1")
+			Expect(issue.Fields.Status.StatusCategory.Key).To(Equal(gojira.StatusCategoryComplete))
+
+			_, err = client.Issue.DoTransition("OSDK-1", "999")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("attachments", func() {
+		It("should store, serve and delete attachments", func() {
+			create("OSDK", "one", "")
+			attachments, _, err := client.Issue.PostAttachment("OSDK-1",
+				strings.NewReader("hello"), "hello.txt")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*attachments).To(HaveLen(1))
+			a := (*attachments)[0]
+			Expect(a.Filename).To(Equal("hello.txt"))
+			Expect(a.Size).To(Equal(5))
+
+			resp, err := client.Issue.DownloadAttachment(a.ID)
+			Expect(err).NotTo(HaveOccurred())
+			data, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			Expect(string(data)).To(Equal("hello"))
+
+			issue, _ := fake.Issue("OSDK-1")
+			Expect(issue.Fields.Attachments).To(HaveLen(1))
+
+			_, err = client.Issue.DeleteAttachment(a.ID)
+			Expect(err).NotTo(HaveOccurred())
+			issue, _ = fake.Issue("OSDK-1")
+			Expect(issue.Fields.Attachments).To(BeEmpty())
+		})
+	})
+
+	Describe("projects, users and versions", func() {
+		It("should describe the projects for createmeta", func() {
+			meta, _, err := client.Issue.GetCreateMeta("SEC")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(meta.Projects).To(HaveLen(1))
+			Expect(meta.Projects[0].Key).To(Equal("SEC"))
+			Expect(meta.Projects[0].IssueTypes).To(HaveLen(1))
+			Expect(meta.Projects[0].IssueTypes[0].Name).To(Equal("Vulnerability"))
+			required, err := meta.Projects[0].IssueTypes[0].GetMandatoryFields()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(required).To(HaveKey("Summary"))
+		})
+		It("should create and list versions", func() {
+			project, _, err := client.Project.Get("OSDK")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(project.Versions).To(BeEmpty())
+
+			v, _, err := client.Version.Create(&gojira.Version{Name: "v1.1.0", ProjectID: atoi(project.ID)})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(v.ID).NotTo(BeEmpty())
+			_, _, err = client.Version.Create(&gojira.Version{Name: "v1.1.0", ProjectID: atoi(project.ID)})
+			Expect(err).To(HaveOccurred())
+
+			req, _ := client.NewRequest("GET", "rest/api/2/project/OSDK/versions", nil)
+			var versions []gojira.Version
+			_, err = client.Do(req, &versions)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(versions).To(HaveLen(2))
+			Expect(versions[0].Name).To(Equal("v1.0.0"))
+		})
+		It("should find users and who is authenticated", func() {
+			me, _, err := client.User.GetSelf()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(me.Name).To(Equal("jdoe"))
+
+			users, _, err := client.User.Find("", gojira.WithUsername("j"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(users).To(HaveLen(2))
+
+			req, _ := client.NewRequest("GET", "rest/api/2/user?username=nobody", nil)
+			_, err = client.Do(req, nil)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("authentication", func() {
+		It("should require the token if given one", func() {
+			var log bytes.Buffer
+			fake = NewFake(WithFakeProject("OSDK", "Operator SDK"), WithFakeToken("s3cret"),
+				WithFakeRequestLog(&log))
+
+			tp := gojira.BearerAuthTransport{Token: "wrong", Transport: NewFakeHTTPClient(fake).Transport}
+			client, _ := gojira.NewClient(tp.Client(), "http://localhost")
+			_, resp, err := client.User.GetSelf()
+			Expect(err).To(HaveOccurred())
+			Expect(resp.StatusCode).To(Equal(401))
+
+			basic := gojira.BasicAuthTransport{Username: "me", Password: "s3cret",
+				Transport: NewFakeHTTPClient(fake).Transport}
+			client, _ = gojira.NewClient(basic.Client(), "http://localhost")
+			me, _, err := client.User.GetSelf()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(me.Name).To(Equal("gh2jira"))
+
+			Expect(log.String()).To(Equal("GET /rest/api/2/myself 401\nGET /rest/api/2/myself 200\n"))
+		})
+	})
+})
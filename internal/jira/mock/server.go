@@ -37,7 +37,8 @@ type MockBackendOption func(*mux.Router)
 // FIFOReponseHandler handler implementation that
 // responds to the HTTP requests following a FIFO approach.
 //
-// Once all available `Responses` have been used, this handler will panic()!
+// Once all available `Responses` have been used, this handler responds with
+// 500 Internal Server Error.
 type FIFOReponseHandler struct {
 	lock         sync.Mutex
 	Responses    [][]byte
@@ -49,11 +50,12 @@ func (srh *FIFOReponseHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 
 	srh.lock.Lock()
 	defer srh.lock.Unlock()
-	if srh.CurrentIndex > len(srh.Responses) {
-		panic(fmt.Sprintf(
+	if srh.CurrentIndex >= len(srh.Responses) {
+		WriteError(w, http.StatusInternalServerError, fmt.Sprintf(
 			"go-github-mock: no more mocks available for %s",
 			r.URL.Path,
 		))
+		return
 	}
 
 	defer func() {
@@ -142,3 +144,38 @@ func NewMockedHTTPClient(options ...MockBackendOption) *http.Client {
 
 	return c
 }
+
+var GetSearch EndpointPattern = EndpointPattern{
+	Pattern: "/rest/api/2/search",
+	Method:  "GET",
+}
+
+var PostIssueLink EndpointPattern = EndpointPattern{
+	Pattern: "/rest/api/2/issueLink",
+	Method:  "POST",
+}
+
+var GetUser EndpointPattern = EndpointPattern{
+	Pattern: "/rest/api/2/user",
+	Method:  "GET",
+}
+
+var GetUserSearch EndpointPattern = EndpointPattern{
+	Pattern: "/rest/api/3/user/search",
+	Method:  "GET",
+}
+
+var GetMyself EndpointPattern = EndpointPattern{
+	Pattern: "/rest/api/2/myself",
+	Method:  "GET",
+}
+
+var GetMyPermissions EndpointPattern = EndpointPattern{
+	Pattern: "/rest/api/2/mypermissions",
+	Method:  "GET",
+}
+
+var PostIssueWatchers EndpointPattern = EndpointPattern{
+	Pattern: "/rest/api/2/issue/{issueIdOrKey}/watchers",
+	Method:  "POST",
+}
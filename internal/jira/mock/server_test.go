@@ -0,0 +1,47 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mock
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FIFOReponseHandler", func() {
+	It("should answer 500 once the responses run out", func() {
+		handler := &FIFOReponseHandler{Responses: [][]byte{[]byte("first"), []byte("second")}}
+		serve := func() *httptest.ResponseRecorder {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest("GET", "/rest/api/2/issue/OSDK-1", nil))
+			return rec
+		}
+
+		for _, want := range []string{"first", "second"} {
+			rec := serve()
+			Expect(rec.Code).To(Equal(http.StatusOK))
+			Expect(rec.Body.String()).To(Equal(want))
+		}
+
+		rec := serve()
+		Expect(rec.Code).To(Equal(http.StatusInternalServerError))
+		body, err := io.ReadAll(rec.Body)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(body)).To(ContainSubstring("no more mocks available for /rest/api/2/issue/OSDK-1"))
+	})
+})
@@ -16,7 +16,6 @@ package mock
 
 import (
 	"encoding/json"
-	"errors"
 	"net/http"
 )
 
@@ -31,13 +30,12 @@ func MustMarshal(v interface{}) []byte {
 	panic(err)
 }
 
-// WriteError helper function to write errors to HTTP handlers
+// WriteError helper function to write errors to HTTP handlers, in the
+// format of Jira so the message reaches the client
 func WriteError(
 	w http.ResponseWriter,
 	httpStatus int,
 	msg string,
 ) {
-	w.WriteHeader(httpStatus)
-
-	w.Write(MustMarshal(errors.New(msg)))
+	writeJiraError(w, httpStatus, msg)
 }
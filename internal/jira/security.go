@@ -0,0 +1,87 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"fmt"
+	"path"
+
+	gojira "github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v47/github"
+)
+
+// DefaultSensitiveLabels are the Github labels that mark an issue as
+// sensitive unless configured otherwise.
+var DefaultSensitiveLabels = []string{"security", "kind/cve"}
+
+// SensitivePolicy decides how issues with sensitive labels are cloned. When a
+// sensitive issue is cloned the Jira security level is set to SecurityLevel
+// and/or the issue is cloned to Project instead. If neither is configured the
+// clone is refused unless AllowSensitive is set.
+type SensitivePolicy struct {
+	// Labels are globs matched against the Github labels
+	Labels         []string
+	SecurityLevel  string
+	Project        string
+	AllowSensitive bool
+}
+
+// sensitiveLabel returns the first label of the issue matching the policy.
+func (p *SensitivePolicy) sensitiveLabel(issue *github.Issue) (string, bool) {
+	for _, l := range issue.Labels {
+		for _, pattern := range p.Labels {
+			if ok, _ := path.Match(pattern, l.GetName()); ok {
+				return l.GetName(), true
+			}
+		}
+	}
+	return "", false
+}
+
+// apply changes the Jira issue according to the policy. It returns a
+// description of what the policy did, or an empty string if the issue is not
+// sensitive.
+func (p *SensitivePolicy) apply(issue *github.Issue, ji *gojira.Issue) (string, error) {
+	if p == nil {
+		return "", nil
+	}
+	label, ok := p.sensitiveLabel(issue)
+	if !ok {
+		return "", nil
+	}
+
+	if p.SecurityLevel == "" && p.Project == "" {
+		if !p.AllowSensitive {
+			return "", fmt.Errorf("issue #%d is labeled %s; refusing to clone it "+
+				"without a security level or restricted project, use --allow-sensitive to override",
+				issue.GetNumber(), label)
+		}
+		return fmt.Sprintf("labeled %s, cloning with default visibility (--allow-sensitive)", label), nil
+	}
+
+	desc := fmt.Sprintf("labeled %s", label)
+	if p.Project != "" {
+		ji.Fields.Project.Key = p.Project
+		desc += fmt.Sprintf(", routed to restricted project %s", p.Project)
+	}
+	if p.SecurityLevel != "" {
+		if ji.Fields.Unknowns == nil {
+			ji.Fields.Unknowns = map[string]interface{}{}
+		}
+		ji.Fields.Unknowns["security"] = map[string]string{"name": p.SecurityLevel}
+		desc += fmt.Sprintf(", security level %q", p.SecurityLevel)
+	}
+	return desc, nil
+}
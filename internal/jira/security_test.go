@@ -0,0 +1,175 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+
+	gojira "github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v47/github"
+	jmock "github.com/jmrodri/gh2jira/internal/jira/mock"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Security", func() {
+	var (
+		ghissue *github.Issue
+		ji      *gojira.Issue
+		policy  *SensitivePolicy
+	)
+	BeforeEach(func() {
+		ghissue = &github.Issue{
+			Number: github.Int(123),
+			Title:  github.String("Issue 1"),
+			URL:    github.String("https://api.github.com/repos/foo/bar/issues/123"),
+			Labels: []*github.Label{
+				{Name: github.String("kind/bug")},
+				{Name: github.String("kind/cve")},
+			},
+		}
+		ji = &gojira.Issue{Fields: &gojira.IssueFields{
+			Project: gojira.Project{Key: "OSDK"},
+		}}
+		policy = &SensitivePolicy{Labels: DefaultSensitiveLabels}
+	})
+
+	Describe("apply", func() {
+		It("should do nothing without a policy", func() {
+			var nilPolicy *SensitivePolicy
+			desc, err := nilPolicy.apply(ghissue, ji)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(desc).To(BeEmpty())
+		})
+		It("should do nothing if the issue is not sensitive", func() {
+			ghissue.Labels = ghissue.Labels[:1]
+			desc, err := policy.apply(ghissue, ji)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(desc).To(BeEmpty())
+			Expect(ji.Fields.Project.Key).To(Equal("OSDK"))
+		})
+		It("should refuse sensitive issues by default", func() {
+			_, err := policy.apply(ghissue, ji)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("issue #123 is labeled kind/cve"))
+			Expect(err.Error()).To(ContainSubstring("--allow-sensitive"))
+		})
+		It("should clone sensitive issues when allowed", func() {
+			policy.AllowSensitive = true
+			desc, err := policy.apply(ghissue, ji)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(desc).To(ContainSubstring("default visibility"))
+		})
+		It("should set the security level and route the issue", func() {
+			policy.Labels = []string{"kind/*"}
+			policy.SecurityLevel = "Red Hat Employee"
+			policy.Project = "SECURE"
+			desc, err := policy.apply(ghissue, ji)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(desc).To(Equal(`labeled kind/bug, routed to restricted project SECURE, ` +
+				`security level "Red Hat Employee"`))
+			Expect(ji.Fields.Project.Key).To(Equal("SECURE"))
+			Expect(ji.Fields.Unknowns["security"]).To(Equal(
+				map[string]string{"name": "Red Hat Employee"}))
+		})
+	})
+
+	Describe("Clone", func() {
+		It("should send the security level to jira", func() {
+			var fields map[string]interface{}
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatchHandler(jmock.PostIssue,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						var body map[string]map[string]interface{}
+						Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+						fields = body["fields"]
+						w.Write(jmock.MustMarshal(gojira.Issue{Key: "SECURE-1"}))
+					}),
+				),
+			)
+			policy.SecurityLevel = "Embargoed"
+			_, err := Clone(ghissue, WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"),
+				WithProject("OSDK"),
+				WithIssueResolver(fakeResolver{}),
+				WithSensitivePolicy(policy),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fields["security"]).To(Equal(map[string]interface{}{"name": "Embargoed"}))
+		})
+		It("should state which policy fired on a dry run", func() {
+			policy.Project = "SECURE"
+
+			r, w, _ := os.Pipe()
+			tmp := os.Stdout
+			defer func() {
+				os.Stdout = tmp
+			}()
+			os.Stdout = w
+			go func() {
+				defer GinkgoRecover()
+				_, err := Clone(ghissue, WithClient(jmock.NewMockedHTTPClient()),
+					WithJiraURL("http://localhost"),
+					WithDryRun(true),
+					WithIssueResolver(fakeResolver{}),
+					WithSensitivePolicy(policy),
+				)
+				w.Close()
+				Expect(err).NotTo(HaveOccurred())
+			}()
+			stdout, _ := io.ReadAll(r)
+
+			Expect(string(stdout)).To(ContainSubstring(
+				"Sensitive issue policy: labeled kind/cve, routed to restricted project SECURE"))
+			Expect(string(stdout)).To(ContainSubstring("jira project board: SECURE"))
+		})
+		It("should say a real run would refuse the issue on a dry run", func() {
+			r, w, _ := os.Pipe()
+			tmp := os.Stdout
+			defer func() {
+				os.Stdout = tmp
+			}()
+			os.Stdout = w
+			go func() {
+				defer GinkgoRecover()
+				_, err := Clone(ghissue, WithClient(jmock.NewMockedHTTPClient()),
+					WithJiraURL("http://localhost"),
+					WithDryRun(true),
+					WithIssueResolver(fakeResolver{}),
+					WithSensitivePolicy(policy),
+				)
+				w.Close()
+				Expect(err).NotTo(HaveOccurred())
+			}()
+			stdout, _ := io.ReadAll(r)
+
+			Expect(string(stdout)).To(ContainSubstring("Sensitive issue policy: issue #"))
+			Expect(string(stdout)).To(ContainSubstring("refusing to clone it"))
+			Expect(string(stdout)).To(ContainSubstring("A real run would refuse to clone this issue."))
+		})
+		It("should not create the issue when the policy refuses it", func() {
+			_, err := Clone(ghissue, WithClient(jmock.NewMockedHTTPClient()),
+				WithJiraURL("http://localhost"),
+				WithIssueResolver(fakeResolver{}),
+				WithSensitivePolicy(policy),
+			)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
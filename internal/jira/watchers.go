@@ -0,0 +1,148 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	gojira "github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v47/github"
+
+	"github.com/jmrodri/gh2jira/internal/redact"
+)
+
+// watcherNames returns the Jira users that should watch the clone of issue:
+// the fixed watchers followed by the mapped Github assignees and
+// participants, without duplicates.
+func watcherNames(issue *github.Issue, config *ClonerConfig) []string {
+	var names []string
+	seen := map[string]bool{}
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	for _, w := range config.watchers {
+		add(w)
+	}
+	if config.watchAssignees {
+		for _, a := range issue.Assignees {
+			add(config.jiraUser(a.GetLogin()))
+		}
+		if len(issue.Assignees) == 0 && issue.GetAssignee() != nil {
+			add(config.jiraUser(issue.GetAssignee().GetLogin()))
+		}
+	}
+	for _, p := range config.participants {
+		add(config.jiraUser(p))
+	}
+	return names
+}
+
+// jiraUser maps a Github login to a Jira username. Logins that are not in the
+// user map are assumed to be the same on both sides.
+func (c *ClonerConfig) jiraUser(login string) string {
+	if name, ok := c.userMap[login]; ok {
+		return name
+	}
+	return login
+}
+
+// watcher is a Jira user to add as a watcher: the name it was given as and
+// the id Jira adds it by, the username on Server and the accountId on Cloud.
+type watcher struct {
+	name string
+	id   string
+}
+
+// resolveWatchers returns the users that exist in Jira, printing a warning
+// for the others. Cloud has no usernames, so with cloud set the users are
+// searched for and added by accountId.
+func resolveWatchers(client *gojira.Client, names []string, cloud bool) []watcher {
+	lookup := userExists
+	if cloud {
+		lookup = cloudUser
+	}
+	var resolved []watcher
+	for _, name := range names {
+		id, err := lookup(client, name)
+		if err != nil {
+			fmt.Fprintf(redact.Stdout, "Warning: skipping watcher %s: %v\n", name, err)
+			continue
+		}
+		resolved = append(resolved, watcher{name: name, id: id})
+	}
+	return resolved
+}
+
+func userExists(client *gojira.Client, name string) (string, error) {
+	req, err := client.NewRequest("GET", "rest/api/2/user?username="+url.QueryEscape(name), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req, nil)
+	if err != nil {
+		if resp != nil && resp.StatusCode == 404 {
+			return "", fmt.Errorf("no such jira user")
+		}
+		return "", gojira.NewJiraError(resp, err)
+	}
+	resp.Body.Close()
+	return name, nil
+}
+
+// cloudUser returns the accountId of the Jira Cloud user matching name. A
+// search can match several users, so an exact accountId, email or display
+// name wins over a single partial match.
+func cloudUser(client *gojira.Client, name string) (string, error) {
+	req, err := client.NewRequest("GET", "rest/api/3/user/search?query="+url.QueryEscape(name), nil)
+	if err != nil {
+		return "", err
+	}
+	var users []gojira.User
+	resp, err := client.Do(req, &users)
+	if err != nil {
+		return "", gojira.NewJiraError(resp, err)
+	}
+	for _, u := range users {
+		if u.AccountID == name || strings.EqualFold(u.EmailAddress, name) ||
+			strings.EqualFold(u.DisplayName, name) {
+			return u.AccountID, nil
+		}
+	}
+	switch len(users) {
+	case 0:
+		return "", fmt.Errorf("no such jira user")
+	case 1:
+		return users[0].AccountID, nil
+	default:
+		return "", fmt.Errorf("%d jira users match", len(users))
+	}
+}
+
+// addWatchers adds the given users as watchers of the Jira issue.
+func addWatchers(client *gojira.Client, key string, watchers []watcher) {
+	for _, w := range watchers {
+		if _, err := client.Issue.AddWatcher(key, w.id); err != nil {
+			fmt.Fprintf(redact.Stdout, "Warning: unable to add watcher %s to %s: %v\n", w.name, key, err)
+			continue
+		}
+		fmt.Fprintf(redact.Stdout, "Added watcher %s to %s\n", w.name, key)
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jira
+
+import (
+	"encoding/json"
+	"net/http"
+
+	gojira "github.com/andygrunwald/go-jira"
+	"github.com/google/go-github/v47/github"
+	"github.com/gorilla/mux"
+	jmock "github.com/jmrodri/gh2jira/internal/jira/mock"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Watchers", func() {
+	var ghissue *github.Issue
+
+	BeforeEach(func() {
+		ghissue = &github.Issue{
+			Number: github.Int(123),
+			Title:  github.String("Issue 1"),
+			URL:    github.String("https://api.github.com/repos/foo/bar/issues/123"),
+			Assignees: []*github.User{
+				{Login: github.String("ghuser1")},
+				{Login: github.String("ghuser2")},
+			},
+		}
+	})
+
+	Describe("watcherNames", func() {
+		It("should return nothing by default", func() {
+			Expect(watcherNames(ghissue, &ClonerConfig{})).To(BeEmpty())
+		})
+		It("should combine fixed, assignee and participant watchers", func() {
+			config := &ClonerConfig{
+				watchers:       []string{"lead", "jirauser1"},
+				watchAssignees: true,
+				participants:   []string{"commenter", "ghuser2"},
+				userMap:        map[string]string{"ghuser1": "jirauser1"},
+			}
+			Expect(watcherNames(ghissue, config)).To(Equal(
+				[]string{"lead", "jirauser1", "ghuser2", "commenter"}))
+		})
+		It("should fall back to the single assignee", func() {
+			ghissue.Assignees = nil
+			ghissue.Assignee = &github.User{Login: github.String("solo")}
+			Expect(watcherNames(ghissue, &ClonerConfig{watchAssignees: true})).To(
+				Equal([]string{"solo"}))
+		})
+	})
+
+	Describe("Clone", func() {
+		It("should add the watchers that exist in jira", func() {
+			var added []string
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.PostIssue, gojira.Issue{Key: "OSDK-200"}),
+				jmock.WithRequestMatchHandler(jmock.GetUser,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						if r.URL.Query().Get("username") == "ghost" {
+							jmock.WriteError(w, http.StatusNotFound, "no such user")
+							return
+						}
+						w.Write(jmock.MustMarshal(gojira.User{Name: r.URL.Query().Get("username")}))
+					}),
+				),
+				jmock.WithRequestMatchHandler(jmock.PostIssueWatchers,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						Expect(mux.Vars(r)["issueIdOrKey"]).To(Equal("OSDK-200"))
+						var name string
+						Expect(json.NewDecoder(r.Body).Decode(&name)).To(Succeed())
+						added = append(added, name)
+						w.WriteHeader(http.StatusNoContent)
+					}),
+				),
+			)
+
+			_, err := Clone(ghissue, WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"),
+				WithIssueResolver(fakeResolver{}),
+				WithWatchers([]string{"lead"}),
+				WithParticipants([]string{"ghost"}),
+				WithWatchAssignees(true),
+				WithUserMap(map[string]string{"ghuser2": "jirauser2"}),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(added).To(Equal([]string{"lead", "ghuser1", "jirauser2"}))
+		})
+		It("should add jira cloud watchers by account id", func() {
+			var added []string
+			accounts := map[string]string{"lead": "5b10ac8d82e05b22cc7d4ef5", "ghuser1": "5b10a2844c20165700ede21g"}
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatch(jmock.PostIssue, gojira.Issue{Key: "OSDK-200"}),
+				jmock.WithRequestMatchHandler(jmock.GetUserSearch,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						users := []gojira.User{}
+						if id, ok := accounts[r.URL.Query().Get("query")]; ok {
+							users = append(users, gojira.User{AccountID: id})
+						}
+						w.Write(jmock.MustMarshal(users))
+					}),
+				),
+				jmock.WithRequestMatchHandler(jmock.PostIssueWatchers,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						var id string
+						Expect(json.NewDecoder(r.Body).Decode(&id)).To(Succeed())
+						added = append(added, id)
+						w.WriteHeader(http.StatusNoContent)
+					}),
+				),
+			)
+
+			_, err := Clone(ghissue, WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"),
+				WithAuth(AuthBasic, "me@example.com"),
+				WithIssueResolver(fakeResolver{}),
+				WithWatchers([]string{"lead", "ghost"}),
+				WithWatchAssignees(true),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(added).To(Equal([]string{accounts["lead"], accounts["ghuser1"]}))
+		})
+		It("should not look the watchers up on a dry run", func() {
+			lookups := 0
+			mockedHTTPClient := jmock.NewMockedHTTPClient(
+				jmock.WithRequestMatchHandler(jmock.GetUser,
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						lookups++
+						w.Write(jmock.MustMarshal(gojira.User{Name: r.URL.Query().Get("username")}))
+					}),
+				),
+			)
+
+			_, err := Clone(ghissue, WithClient(mockedHTTPClient),
+				WithJiraURL("http://localhost"),
+				WithIssueResolver(fakeResolver{}),
+				WithWatchers([]string{"lead"}),
+				WithDryRun(true),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lookups).To(BeZero())
+		})
+	})
+})
@@ -0,0 +1,49 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package jiraauth names the ways gh2jira authenticates to Jira. It is shared
+// by the jira client and the configuration, which validates the profiles.
+package jiraauth
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// Bearer sends the token as a bearer token, a personal access token of
+	// Jira Server and Data Center.
+	Bearer = "bearer"
+	// Basic sends the user and the token as basic auth, an API token of Jira
+	// Cloud.
+	Basic = "basic"
+)
+
+// Modes are the ways to authenticate to Jira.
+var Modes = []string{Bearer, Basic}
+
+// Validate checks the auth mode and that basic auth has a user. An empty mode
+// means bearer.
+func Validate(mode, user string) error {
+	switch mode {
+	case "", Bearer:
+	case Basic:
+		if user == "" {
+			return fmt.Errorf("%s auth requires a jira user", Basic)
+		}
+	default:
+		return fmt.Errorf("invalid jira auth mode %q, must be one of %s", mode, strings.Join(Modes, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jiraauth
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Validate", func() {
+	It("should accept every mode", func() {
+		Expect(Validate("", "")).To(Succeed())
+		Expect(Validate(Bearer, "")).To(Succeed())
+		Expect(Validate(Basic, "jdoe@example.com")).To(Succeed())
+	})
+	It("should require a user for basic auth", func() {
+		Expect(Validate(Basic, "")).To(MatchError("basic auth requires a jira user"))
+	})
+	It("should reject unknown modes", func() {
+		Expect(Validate("oauth", "")).To(MatchError(`invalid jira auth mode "oauth", must be one of bearer, basic`))
+	})
+})
@@ -0,0 +1,42 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mapping
+
+import (
+	"github.com/google/go-github/v47/github"
+)
+
+// Drift returns how the Github issue and its Jira counterpart have diverged
+// since the issue was cloned or last synced. jiraDone tells whether the Jira
+// issue is closed. An empty result means they are in sync.
+func Drift(rec *Record, issue *github.Issue, jiraDone bool) []string {
+	var drift []string
+
+	if rec.TitleHash != "" && rec.TitleHash != Hash(issue.GetTitle()) {
+		drift = append(drift, "title changed")
+	}
+	if rec.BodyHash != "" && rec.BodyHash != Hash(issue.GetBody()) {
+		drift = append(drift, "body changed")
+	}
+
+	githubClosed := issue.GetState() == "closed"
+	if githubClosed && !jiraDone {
+		drift = append(drift, "github closed, jira open")
+	} else if !githubClosed && jiraDone {
+		drift = append(drift, "jira closed, github open")
+	}
+
+	return drift
+}
@@ -0,0 +1,64 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mapping
+
+import (
+	"github.com/google/go-github/v47/github"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Drift", func() {
+	var (
+		rec   *Record
+		issue *github.Issue
+	)
+	BeforeEach(func() {
+		rec = &Record{
+			Org: "foo", Repo: "bar", Number: 1, JiraKey: "OSDK-1",
+			TitleHash: Hash("title"),
+			BodyHash:  Hash("body"),
+		}
+		issue = &github.Issue{
+			Number: github.Int(1),
+			Title:  github.String("title"),
+			Body:   github.String("body"),
+			State:  github.String("open"),
+		}
+	})
+	It("should report nothing if the issues are in sync", func() {
+		Expect(Drift(rec, issue, false)).To(BeEmpty())
+	})
+	It("should report title and body changes", func() {
+		issue.Title = github.String("new title")
+		issue.Body = github.String("new body")
+		Expect(Drift(rec, issue, false)).To(Equal([]string{"title changed", "body changed"}))
+	})
+	It("should ignore hashes that were never recorded", func() {
+		rec.TitleHash = ""
+		rec.BodyHash = ""
+		issue.Title = github.String("new title")
+		Expect(Drift(rec, issue, false)).To(BeEmpty())
+	})
+	It("should report github closed but jira open", func() {
+		issue.State = github.String("closed")
+		Expect(Drift(rec, issue, false)).To(Equal([]string{"github closed, jira open"}))
+		Expect(Drift(rec, issue, true)).To(BeEmpty())
+	})
+	It("should report jira closed but github open", func() {
+		Expect(Drift(rec, issue, true)).To(Equal([]string{"jira closed, github open"}))
+	})
+})
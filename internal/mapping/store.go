@@ -0,0 +1,271 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mapping
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const fileVersion = 1
+
+// Record maps a Github issue to the Jira issue it was cloned to.
+type Record struct {
+	Org      string    `json:"org"`
+	Repo     string    `json:"repo"`
+	Number   int       `json:"number"`
+	JiraKey  string    `json:"jiraKey"`
+	ClonedAt time.Time `json:"clonedAt"`
+	SyncedAt time.Time `json:"syncedAt"`
+	// hashes of the Github issue at the time it was last synced
+	TitleHash string `json:"titleHash,omitempty"`
+	BodyHash  string `json:"bodyHash,omitempty"`
+}
+
+// ID returns the Github issue reference, i.e. org/repo#number
+func (r Record) ID() string {
+	return issueID(r.Org, r.Repo, r.Number)
+}
+
+type storeFile struct {
+	Version int      `json:"version"`
+	Issues  []Record `json:"issues"`
+}
+
+// Store is a JSON file of Records. Every operation takes a file lock so
+// several gh2jira processes can share the same store.
+type Store struct {
+	path string
+}
+
+// DefaultPath returns the location of the store in the user's state
+// directory, $XDG_STATE_HOME/gh2jira/mapping.json.
+func DefaultPath() (string, error) {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "gh2jira", "mapping.json"), nil
+}
+
+// Open returns the store at path. If path is empty the DefaultPath is used.
+// The file is created on the first write.
+func Open(path string) (*Store, error) {
+	if path == "" {
+		var err error
+		if path, err = DefaultPath(); err != nil {
+			return nil, err
+		}
+	}
+	return &Store{path: path}, nil
+}
+
+// Path returns the file backing the store.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Get returns the record of the given Github issue, or nil if it has not
+// been cloned.
+func (s *Store) Get(org, repo string, number int) (*Record, error) {
+	var rec *Record
+	err := s.view(func(records map[string]Record) {
+		if r, ok := records[issueID(org, repo, number)]; ok {
+			rec = &r
+		}
+	})
+	return rec, err
+}
+
+// Resolve returns the Jira key the Github issue was cloned to, or an empty
+// string if it has not been cloned.
+func (s *Store) Resolve(org, repo string, number int) (string, error) {
+	rec, err := s.Get(org, repo, number)
+	if err != nil || rec == nil {
+		return "", err
+	}
+	return rec.JiraKey, nil
+}
+
+// List returns all records sorted by Github issue.
+func (s *Store) List() ([]Record, error) {
+	var list []Record
+	err := s.view(func(records map[string]Record) {
+		list = sortedRecords(records)
+	})
+	return list, err
+}
+
+// Put adds or replaces the record of a Github issue.
+func (s *Store) Put(rec Record) error {
+	if err := validate(rec); err != nil {
+		return err
+	}
+	return s.update(func(records map[string]Record) {
+		records[rec.ID()] = rec
+	})
+}
+
+// Import merges the given records into the store, replacing existing records
+// of the same Github issue. It returns the number of records imported.
+func (s *Store) Import(recs []Record) (int, error) {
+	for _, rec := range recs {
+		if err := validate(rec); err != nil {
+			return 0, err
+		}
+	}
+	err := s.update(func(records map[string]Record) {
+		for _, rec := range recs {
+			records[rec.ID()] = rec
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(recs), nil
+}
+
+// Hash returns the hash stored in a Record for the given title or body.
+func Hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *Store) view(f func(map[string]Record)) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	records, err := s.read()
+	if err != nil {
+		return err
+	}
+	f(records)
+	return nil
+}
+
+func (s *Store) update(f func(map[string]Record)) error {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	records, err := s.read()
+	if err != nil {
+		return err
+	}
+	f(records)
+	return s.write(records)
+}
+
+func (s *Store) lock() (func() error, error) {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return nil, err
+	}
+	return lockFile(s.path + ".lock")
+}
+
+func (s *Store) read() (map[string]Record, error) {
+	records := map[string]Record{}
+
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return records, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var sf storeFile
+	if err := json.Unmarshal(data, &sf); err != nil {
+		return nil, fmt.Errorf("unable to read mapping store %s: %w", s.path, err)
+	}
+	if sf.Version > fileVersion {
+		return nil, fmt.Errorf("mapping store %s has unsupported version %d",
+			s.path, sf.Version)
+	}
+	for _, rec := range sf.Issues {
+		records[rec.ID()] = rec
+	}
+	return records, nil
+}
+
+// write replaces the store file atomically so readers never see a partially
+// written file.
+func (s *Store) write(records map[string]Record) error {
+	data, err := json.MarshalIndent(storeFile{
+		Version: fileVersion,
+		Issues:  sortedRecords(records),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".mapping-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func sortedRecords(records map[string]Record) []Record {
+	list := make([]Record, 0, len(records))
+	for _, rec := range records {
+		list = append(list, rec)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Org != list[j].Org {
+			return list[i].Org < list[j].Org
+		}
+		if list[i].Repo != list[j].Repo {
+			return list[i].Repo < list[j].Repo
+		}
+		return list[i].Number < list[j].Number
+	})
+	return list
+}
+
+func validate(rec Record) error {
+	if rec.Org == "" || rec.Repo == "" || rec.Number <= 0 || rec.JiraKey == "" {
+		return fmt.Errorf("invalid mapping record %s -> %q", rec.ID(), rec.JiraKey)
+	}
+	return nil
+}
+
+func issueID(org, repo string, number int) string {
+	return fmt.Sprintf("%s/%s#%d", org, repo, number)
+}
@@ -0,0 +1,168 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mapping
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Store", func() {
+	var (
+		dir   string
+		store *Store
+	)
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "mapping")
+		Expect(err).NotTo(HaveOccurred())
+		store, err = Open(filepath.Join(dir, "state", "mapping.json"))
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	Describe("DefaultPath", func() {
+		It("should use XDG_STATE_HOME if it is set", func() {
+			tmp := os.Getenv("XDG_STATE_HOME")
+			defer os.Setenv("XDG_STATE_HOME", tmp)
+
+			os.Setenv("XDG_STATE_HOME", "/tmp/state")
+			path, err := DefaultPath()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(path).To(Equal("/tmp/state/gh2jira/mapping.json"))
+		})
+	})
+
+	Describe("Get", func() {
+		It("should return nil if the store does not exist yet", func() {
+			rec, err := store.Get("foo", "bar", 1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rec).To(BeNil())
+		})
+		It("should return an error if the store is corrupt", func() {
+			Expect(os.MkdirAll(filepath.Dir(store.Path()), 0o700)).To(Succeed())
+			Expect(os.WriteFile(store.Path(), []byte("{"), 0o600)).To(Succeed())
+			_, err := store.Get("foo", "bar", 1)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("unable to read mapping store"))
+		})
+	})
+
+	Describe("Put", func() {
+		It("should store and replace records", func() {
+			now := time.Now().UTC().Truncate(time.Second)
+			Expect(store.Put(Record{Org: "foo", Repo: "bar", Number: 1,
+				JiraKey: "OSDK-1", ClonedAt: now})).To(Succeed())
+			Expect(store.Put(Record{Org: "foo", Repo: "bar", Number: 1,
+				JiraKey: "OSDK-2", ClonedAt: now})).To(Succeed())
+
+			rec, err := store.Get("foo", "bar", 1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rec.JiraKey).To(Equal("OSDK-2"))
+			Expect(rec.ClonedAt).To(Equal(now))
+
+			key, err := store.Resolve("foo", "bar", 1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(key).To(Equal("OSDK-2"))
+		})
+		It("should reject incomplete records", func() {
+			err := store.Put(Record{Org: "foo", Repo: "bar", Number: 1})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("invalid mapping record foo/bar#1"))
+		})
+		It("should write the store readable only by the user", func() {
+			Expect(store.Put(Record{Org: "foo", Repo: "bar", Number: 1,
+				JiraKey: "OSDK-1"})).To(Succeed())
+			fi, err := os.Stat(store.Path())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fi.Mode().Perm()).To(Equal(os.FileMode(0o600)))
+		})
+		It("should not lose records written concurrently", func() {
+			var wg sync.WaitGroup
+			for i := 1; i <= 20; i++ {
+				wg.Add(1)
+				go func(n int) {
+					defer wg.Done()
+					defer GinkgoRecover()
+					// use separate Store values like separate processes would
+					s, _ := Open(store.Path())
+					Expect(s.Put(Record{Org: "foo", Repo: "bar", Number: n,
+						JiraKey: "OSDK-1"})).To(Succeed())
+				}(i)
+			}
+			wg.Wait()
+
+			records, err := store.List()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(records).To(HaveLen(20))
+		})
+	})
+
+	Describe("List", func() {
+		It("should sort records by github issue", func() {
+			Expect(store.Put(Record{Org: "foo", Repo: "bar", Number: 10, JiraKey: "A-1"})).To(Succeed())
+			Expect(store.Put(Record{Org: "foo", Repo: "bar", Number: 2, JiraKey: "A-2"})).To(Succeed())
+			Expect(store.Put(Record{Org: "abc", Repo: "bar", Number: 5, JiraKey: "A-3"})).To(Succeed())
+
+			records, err := store.List()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(records).To(HaveLen(3))
+			Expect(records[0].ID()).To(Equal("abc/bar#5"))
+			Expect(records[1].ID()).To(Equal("foo/bar#2"))
+			Expect(records[2].ID()).To(Equal("foo/bar#10"))
+		})
+	})
+
+	Describe("Import", func() {
+		It("should merge records into the store", func() {
+			Expect(store.Put(Record{Org: "foo", Repo: "bar", Number: 1, JiraKey: "A-1"})).To(Succeed())
+			n, err := store.Import([]Record{
+				{Org: "foo", Repo: "bar", Number: 1, JiraKey: "A-10"},
+				{Org: "foo", Repo: "bar", Number: 2, JiraKey: "A-2"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(n).To(Equal(2))
+
+			records, err := store.List()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(records).To(HaveLen(2))
+			Expect(records[0].JiraKey).To(Equal("A-10"))
+		})
+		It("should import nothing if any record is invalid", func() {
+			_, err := store.Import([]Record{
+				{Org: "foo", Repo: "bar", Number: 1, JiraKey: "A-1"},
+				{Org: "foo", Repo: "bar", Number: 2},
+			})
+			Expect(err).To(HaveOccurred())
+			records, err := store.List()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(records).To(BeEmpty())
+		})
+	})
+
+	Describe("Hash", func() {
+		It("should be stable", func() {
+			Expect(Hash("title")).To(Equal(Hash("title")))
+			Expect(Hash("title")).NotTo(Equal(Hash("title2")))
+		})
+	})
+})
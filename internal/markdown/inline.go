@@ -0,0 +1,194 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package markdown
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	linkRegex     = regexp.MustCompile(`^!?\[([^\]]*)\]\(\s*<?([^)\s>]*)>?(?:\s+"[^"]*")?\s*\)`)
+	autolinkRegex = regexp.MustCompile(`^<(https?://[^>\s]+)>`)
+	urlRegex      = regexp.MustCompile(`^https?://[^\s<]+`)
+	mentionRegex  = regexp.MustCompile(`^@([A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?(?:/[\w.-]+)?)`)
+)
+
+// ParseInline parses the inline Markdown of a single line of text.
+func ParseInline(s string) []Inline {
+	p := &inlineParser{}
+	p.parse(s, Inline{})
+	return p.out
+}
+
+type inlineParser struct {
+	out []Inline
+	buf strings.Builder
+	cur Inline
+}
+
+// text adds s to the current run, starting a new run if the formatting
+// changed.
+func (p *inlineParser) text(s string, style Inline) {
+	if s == "" {
+		return
+	}
+	if p.buf.Len() > 0 && style != p.cur {
+		p.flush()
+	}
+	p.cur = style
+	p.buf.WriteString(s)
+}
+
+func (p *inlineParser) flush() {
+	if p.buf.Len() > 0 {
+		run := p.cur
+		run.Text = p.buf.String()
+		p.out = append(p.out, run)
+		p.buf.Reset()
+	}
+}
+
+func (p *inlineParser) add(in Inline) {
+	p.flush()
+	p.out = append(p.out, in)
+}
+
+func (p *inlineParser) parse(s string, style Inline) {
+	for i := 0; i < len(s); {
+		c := s[i]
+		rest := s[i:]
+
+		switch {
+		case c == '\\' && i+1 < len(s) && strings.IndexByte("\\`*_{}[]()#+-.!~<>|@", s[i+1]) != -1:
+			p.text(s[i+1:i+2], style)
+			i += 2
+			continue
+
+		case c == '`':
+			ticks := len(rest) - len(strings.TrimLeft(rest, "`"))
+			fence := rest[:ticks]
+			if end := strings.Index(rest[ticks:], fence); end != -1 {
+				code := strings.TrimSpace(rest[ticks : ticks+end])
+				st := Inline{Code: true, URL: style.URL}
+				p.text(code, st)
+				i += ticks + end + ticks
+				continue
+			}
+			p.text(fence, style)
+			i += ticks
+			continue
+
+		case (c == '[' || (c == '!' && strings.HasPrefix(rest, "!["))) && style.URL == "":
+			if m := linkRegex.FindStringSubmatch(rest); m != nil {
+				st := style
+				st.URL = m[2]
+				text := m[1]
+				if text == "" {
+					text = m[2]
+				}
+				p.parse(text, st)
+				i += len(m[0])
+				continue
+			}
+
+		case c == '<' && style.URL == "":
+			if m := autolinkRegex.FindStringSubmatch(rest); m != nil {
+				st := style
+				st.URL = m[1]
+				p.text(m[1], st)
+				i += len(m[0])
+				continue
+			}
+
+		case c == 'h' && style.URL == "" && (i == 0 || !isWord(s[i-1])):
+			if m := urlRegex.FindString(rest); m != "" {
+				m = strings.TrimRight(m, ".,:;!?'\")")
+				st := style
+				st.URL = m
+				p.text(m, st)
+				i += len(m)
+				continue
+			}
+
+		case c == '@' && style.URL == "" && (i == 0 || !isWord(s[i-1])):
+			if m := mentionRegex.FindStringSubmatch(rest); m != nil {
+				p.add(Inline{Kind: Mention, Text: m[1]})
+				i += len(m[0])
+				continue
+			}
+
+		case c == '*' || c == '_' || c == '~':
+			if n, inner, ok := emphasis(s, i); ok {
+				st := style
+				switch {
+				case c == '~':
+					st.Strike = true
+				case n == 2:
+					st.Strong = true
+				case n == 3:
+					st.Strong = true
+					st.Emphasis = true
+				default:
+					st.Emphasis = true
+				}
+				p.parse(inner, st)
+				i += n + len(inner) + n
+				continue
+			}
+		}
+
+		p.text(s[i:i+1], style)
+		i++
+	}
+	p.flush()
+}
+
+// emphasis looks for emphasis delimited by the run of *, _ or ~ at s[i]. It
+// returns the length of the delimiter and the emphasized text.
+func emphasis(s string, i int) (int, string, bool) {
+	c := s[i]
+	n := 0
+	for i+n < len(s) && s[i+n] == c && n < 3 {
+		n++
+	}
+	if c == '~' && n != 2 {
+		return 0, "", false
+	}
+	delim := strings.Repeat(string(c), n)
+	start := i + n
+	// the opening delimiter must be followed by text and, for _, must not be
+	// inside a word like snake_case
+	if start >= len(s) || s[start] == ' ' || (c == '_' && i > 0 && isWord(s[i-1])) {
+		return 0, "", false
+	}
+	for j := start + 1; j+n <= len(s); j++ {
+		if s[j:j+n] != delim || s[j-1] == ' ' {
+			continue
+		}
+		if j+n < len(s) && s[j+n] == c {
+			continue
+		}
+		if c == '_' && j+n < len(s) && isWord(s[j+n]) {
+			continue
+		}
+		return n, s[start:j], true
+	}
+	return 0, "", false
+}
+
+func isWord(b byte) bool {
+	return b == '_' || ('a' <= b && b <= 'z') || ('A' <= b && b <= 'Z') || ('0' <= b && b <= '9')
+}
@@ -0,0 +1,259 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package markdown parses the subset of Github flavored Markdown found in
+// issues and comments into blocks and inline text runs that the renderers
+// can walk.
+package markdown
+
+import (
+	"regexp"
+	"strings"
+)
+
+// BlockKind is the type of a Block.
+type BlockKind int
+
+const (
+	Paragraph BlockKind = iota
+	Heading
+	CodeBlock
+	List
+	Quote
+	Rule
+)
+
+// Block is a block level element of a document.
+type Block struct {
+	Kind BlockKind
+	// Inlines of a Paragraph or Heading
+	Inlines []Inline
+	// Level of a Heading, 1 to 6
+	Level int
+	// Language and Text of a CodeBlock
+	Language string
+	Text     string
+	// Ordered tells whether a List is numbered and Start is its first
+	// number, Items are the blocks of each list item
+	Ordered bool
+	Start   int
+	Items   [][]Block
+	// Children of a Quote
+	Children []Block
+}
+
+// InlineKind is the type of an Inline.
+type InlineKind int
+
+const (
+	Text InlineKind = iota
+	Mention
+	Break
+)
+
+// Inline is a run of text sharing the same formatting. Links are text runs
+// with a URL.
+type Inline struct {
+	Kind     InlineKind
+	Text     string
+	URL      string
+	Strong   bool
+	Emphasis bool
+	Strike   bool
+	Code     bool
+}
+
+var (
+	commentRegex = regexp.MustCompile(`(?s)<!--.*?-->`)
+	fenceRegex   = regexp.MustCompile("^ {0,3}(```+|~~~+)\\s*([^`\\s]*)")
+	headingRegex = regexp.MustCompile(`^ {0,3}(#{1,6})(?:\s+(.*?))?\s*#*\s*$`)
+	ruleRegex    = regexp.MustCompile(`^ {0,3}([-*_])(?:\s*([-*_]))(?:\s*([-*_]))[-*_\s]*$`)
+	listRegex    = regexp.MustCompile(`^(\s*)([-*+]|(\d{1,9})[.)])(\s+|$)`)
+	quoteRegex   = regexp.MustCompile(`^ {0,3}> ?`)
+)
+
+// Parse parses the given Markdown. HTML comments, common in Github issue
+// templates, are dropped.
+func Parse(src string) []Block {
+	src = commentRegex.ReplaceAllString(src, "")
+	src = strings.ReplaceAll(src, "\r\n", "\n")
+	src = strings.ReplaceAll(src, "\t", "    ")
+	return parseBlocks(strings.Split(src, "\n"))
+}
+
+func parseBlocks(lines []string) []Block {
+	var blocks []Block
+	var para []string
+
+	flush := func() {
+		if len(para) > 0 {
+			blocks = append(blocks, Block{Kind: Paragraph, Inlines: parseParagraph(para)})
+			para = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		switch {
+		case strings.TrimSpace(line) == "":
+			flush()
+
+		case fenceRegex.MatchString(line):
+			flush()
+			m := fenceRegex.FindStringSubmatch(line)
+			fence := m[1]
+			var code []string
+			for i++; i < len(lines); i++ {
+				if strings.HasPrefix(strings.TrimSpace(lines[i]), fence) {
+					break
+				}
+				code = append(code, lines[i])
+			}
+			blocks = append(blocks, Block{
+				Kind:     CodeBlock,
+				Language: m[2],
+				Text:     strings.Join(code, "\n"),
+			})
+
+		case headingRegex.MatchString(line):
+			flush()
+			m := headingRegex.FindStringSubmatch(line)
+			blocks = append(blocks, Block{
+				Kind:    Heading,
+				Level:   len(m[1]),
+				Inlines: ParseInline(m[2]),
+			})
+
+		case ruleRegex.MatchString(line) && isRule(line):
+			flush()
+			blocks = append(blocks, Block{Kind: Rule})
+
+		case quoteRegex.MatchString(line):
+			flush()
+			var quoted []string
+			for ; i < len(lines) && quoteRegex.MatchString(lines[i]); i++ {
+				quoted = append(quoted, quoteRegex.ReplaceAllString(lines[i], ""))
+			}
+			i--
+			blocks = append(blocks, Block{Kind: Quote, Children: parseBlocks(quoted)})
+
+		case listRegex.MatchString(line) && (len(para) == 0 || startsList(line)):
+			flush()
+			var list Block
+			list, i = parseList(lines, i)
+			blocks = append(blocks, list)
+
+		default:
+			para = append(para, line)
+		}
+	}
+	flush()
+	return blocks
+}
+
+// isRule makes sure all the markers of a thematic break are the same
+func isRule(line string) bool {
+	s := strings.ReplaceAll(strings.TrimSpace(line), " ", "")
+	return len(s) >= 3 && strings.Count(s, s[:1]) == len(s)
+}
+
+// startsList tells whether a list item can interrupt a paragraph, like
+// Github only bullets and lists starting at 1 can.
+func startsList(line string) bool {
+	m := listRegex.FindStringSubmatch(line)
+	return m[4] != "" && (m[3] == "" || m[3] == "1")
+}
+
+// parseList parses the list starting at lines[start]. It returns the list and
+// the index of its last line.
+func parseList(lines []string, start int) (Block, int) {
+	first := listRegex.FindStringSubmatch(lines[start])
+	list := Block{Kind: List, Ordered: first[3] != ""}
+	if list.Ordered {
+		list.Start = atoi(first[3])
+	}
+	indent := len(first[1])
+
+	var item []string
+	var contentIndent int
+	flush := func() {
+		if item != nil {
+			list.Items = append(list.Items, parseBlocks(item))
+		}
+	}
+
+	i := start
+	blank := false
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		m := listRegex.FindStringSubmatch(line)
+		lead := len(line) - len(strings.TrimLeft(line, " "))
+
+		switch {
+		case m != nil && len(m[1]) == indent && (m[3] != "") == list.Ordered:
+			// next item of this list
+			flush()
+			contentIndent = len(m[0])
+			if m[4] == "" {
+				contentIndent++
+			}
+			item = []string{line[len(m[0]):]}
+			blank = false
+		case strings.TrimSpace(line) == "":
+			item = append(item, "")
+			blank = true
+		case lead >= contentIndent || (lead > indent && m != nil):
+			// nested content of the current item
+			item = append(item, strings.TrimPrefix(line, strings.Repeat(" ", min(lead, contentIndent))))
+			blank = false
+		case !blank && m == nil && !fenceRegex.MatchString(line) &&
+			!headingRegex.MatchString(line) && !quoteRegex.MatchString(line):
+			// lazy continuation of the item's paragraph
+			item = append(item, strings.TrimLeft(line, " "))
+		default:
+			flush()
+			return list, i - 1
+		}
+	}
+	flush()
+	return list, i - 1
+}
+
+func parseParagraph(lines []string) []Inline {
+	var inlines []Inline
+	for i, line := range lines {
+		if i > 0 {
+			// Github renders the newlines of issues and comments as breaks
+			inlines = append(inlines, Inline{Kind: Break})
+		}
+		inlines = append(inlines, ParseInline(strings.TrimSpace(line))...)
+	}
+	return inlines
+}
+
+func atoi(s string) int {
+	n := 0
+	for _, c := range s {
+		n = n*10 + int(c-'0')
+	}
+	return n
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,125 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package markdown
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Markdown", func() {
+	Describe("Parse", func() {
+		It("should split paragraphs on blank lines", func() {
+			blocks := Parse("first\nline\n\nsecond")
+			Expect(blocks).To(HaveLen(2))
+			Expect(blocks[0].Kind).To(Equal(Paragraph))
+			Expect(blocks[0].Inlines).To(Equal([]Inline{
+				{Text: "first"}, {Kind: Break}, {Text: "line"},
+			}))
+			Expect(blocks[1].Inlines).To(Equal([]Inline{{Text: "second"}}))
+		})
+		It("should drop html comments", func() {
+			blocks := Parse("<!-- fill in the template -->\nbody")
+			Expect(blocks).To(HaveLen(1))
+			Expect(blocks[0].Inlines).To(Equal([]Inline{{Text: "body"}}))
+		})
+		It("should parse headings", func() {
+			blocks := Parse("## Bug Report ##")
+			Expect(blocks).To(HaveLen(1))
+			Expect(blocks[0].Kind).To(Equal(Heading))
+			Expect(blocks[0].Level).To(Equal(2))
+			Expect(blocks[0].Inlines).To(Equal([]Inline{{Text: "Bug Report"}}))
+		})
+		It("should parse fenced code blocks verbatim", func() {
+			blocks := Parse("```go\nfunc *main*() {}\n\n```\nafter")
+			Expect(blocks).To(HaveLen(2))
+			Expect(blocks[0].Kind).To(Equal(CodeBlock))
+			Expect(blocks[0].Language).To(Equal("go"))
+			Expect(blocks[0].Text).To(Equal("func *main*() {}\n"))
+			Expect(blocks[1].Kind).To(Equal(Paragraph))
+		})
+		It("should parse rules", func() {
+			blocks := Parse("above\n\n---\n\nbelow")
+			Expect(blocks).To(HaveLen(3))
+			Expect(blocks[1].Kind).To(Equal(Rule))
+		})
+		It("should parse quotes", func() {
+			blocks := Parse("> quoted\n> text")
+			Expect(blocks).To(HaveLen(1))
+			Expect(blocks[0].Kind).To(Equal(Quote))
+			Expect(blocks[0].Children).To(HaveLen(1))
+			Expect(blocks[0].Children[0].Inlines).To(HaveLen(3))
+		})
+		It("should parse bullet lists", func() {
+			blocks := Parse("- one\n- two\n  - nested\n")
+			Expect(blocks).To(HaveLen(1))
+			Expect(blocks[0].Kind).To(Equal(List))
+			Expect(blocks[0].Ordered).To(BeFalse())
+			Expect(blocks[0].Items).To(HaveLen(2))
+			Expect(blocks[0].Items[1]).To(HaveLen(2))
+			Expect(blocks[0].Items[1][1].Kind).To(Equal(List))
+		})
+		It("should parse ordered lists", func() {
+			blocks := Parse("3. three\n4. four")
+			Expect(blocks).To(HaveLen(1))
+			Expect(blocks[0].Ordered).To(BeTrue())
+			Expect(blocks[0].Start).To(Equal(3))
+			Expect(blocks[0].Items).To(HaveLen(2))
+		})
+		It("should end a list at a paragraph", func() {
+			blocks := Parse("- one\n\nnot in the list")
+			Expect(blocks).To(HaveLen(2))
+			Expect(blocks[1].Kind).To(Equal(Paragraph))
+		})
+	})
+
+	Describe("ParseInline", func() {
+		It("should parse emphasis", func() {
+			Expect(ParseInline("a **b** _c_ ~~d~~")).To(Equal([]Inline{
+				{Text: "a "}, {Text: "b", Strong: true}, {Text: " "},
+				{Text: "c", Emphasis: true}, {Text: " "}, {Text: "d", Strike: true},
+			}))
+		})
+		It("should not treat snake_case as emphasis", func() {
+			Expect(ParseInline("some_snake_case")).To(Equal([]Inline{{Text: "some_snake_case"}}))
+		})
+		It("should parse code spans", func() {
+			Expect(ParseInline("run `make *all*`")).To(Equal([]Inline{
+				{Text: "run "}, {Text: "make *all*", Code: true},
+			}))
+		})
+		It("should parse links", func() {
+			Expect(ParseInline("[the docs](https://sdk.operatorframework.io)")).To(Equal([]Inline{
+				{Text: "the docs", URL: "https://sdk.operatorframework.io"},
+			}))
+		})
+		It("should parse bare URLs without trailing punctuation", func() {
+			Expect(ParseInline("see https://github.com/foo.")).To(Equal([]Inline{
+				{Text: "see "}, {Text: "https://github.com/foo", URL: "https://github.com/foo"}, {Text: "."},
+			}))
+		})
+		It("should parse mentions", func() {
+			Expect(ParseInline("cc @jmrodri")).To(Equal([]Inline{
+				{Text: "cc "}, {Kind: Mention, Text: "jmrodri"},
+			}))
+		})
+		It("should not parse email addresses as mentions", func() {
+			Expect(ParseInline("foo@example.com")).To(Equal([]Inline{{Text: "foo@example.com"}}))
+		})
+		It("should honor escapes", func() {
+			Expect(ParseInline(`\*not emphasis\*`)).To(Equal([]Inline{{Text: "*not emphasis*"}}))
+		})
+	})
+})
@@ -0,0 +1,153 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package markdown
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	bold      = "\033[1m"
+	italic    = "\033[3m"
+	strike    = "\033[9m"
+	dim       = "\033[2m"
+	cyan      = "\033[36m"
+	underline = "\033[4m"
+	reset     = "\033[0m"
+)
+
+// Terminal renders the Markdown as text for a terminal. Formatting is shown
+// with ANSI escape codes when color is true, and dropped otherwise.
+func Terminal(src string, color bool) string {
+	r := &terminal{color: color}
+	r.blocks(Parse(src), "")
+	return strings.TrimRight(r.sb.String(), "\n") + "\n"
+}
+
+type terminal struct {
+	sb    strings.Builder
+	color bool
+}
+
+func (r *terminal) style(code, s string) string {
+	if !r.color || s == "" {
+		return s
+	}
+	return code + s + reset
+}
+
+// blocks renders the blocks, prefixing every line with indent.
+func (r *terminal) blocks(blocks []Block, indent string) {
+	for i, b := range blocks {
+		// keep lists tight to the paragraph introducing them
+		if i > 0 && !(b.Kind == List && blocks[i-1].Kind == Paragraph) {
+			r.sb.WriteString(strings.TrimRight(indent, " ") + "\n")
+		}
+		r.block(b, indent)
+	}
+}
+
+func (r *terminal) block(b Block, indent string) {
+	switch b.Kind {
+	case Heading:
+		r.line(indent, r.style(bold, strings.Repeat("#", b.Level)+" "+r.inlines(b.Inlines)))
+	case CodeBlock:
+		for _, l := range strings.Split(b.Text, "\n") {
+			r.line(indent+"    ", r.style(cyan, l))
+		}
+	case List:
+		n := b.Start
+		for _, item := range b.Items {
+			marker := "• "
+			if b.Ordered {
+				marker = fmt.Sprintf("%d. ", n)
+				n++
+			}
+			// render the item, then put the marker in front of its first line
+			sub := &terminal{color: r.color}
+			sub.blocks(item, "")
+			lines := strings.Split(strings.TrimRight(sub.sb.String(), "\n"), "\n")
+			pad := strings.Repeat(" ", len([]rune(marker)))
+			for i, l := range lines {
+				if i == 0 {
+					r.line(indent, marker+l)
+				} else {
+					r.line(indent+pad, l)
+				}
+			}
+		}
+	case Quote:
+		sub := &terminal{color: r.color}
+		sub.blocks(b.Children, "")
+		for _, l := range strings.Split(strings.TrimRight(sub.sb.String(), "\n"), "\n") {
+			r.line(indent+r.style(dim, "│ "), l)
+		}
+	case Rule:
+		r.line(indent, r.style(dim, strings.Repeat("─", 40)))
+	default:
+		r.line(indent, r.inlines(b.Inlines))
+	}
+}
+
+// line writes one or more lines, all prefixed with indent.
+func (r *terminal) line(indent, s string) {
+	for _, l := range strings.Split(s, "\n") {
+		r.sb.WriteString(strings.TrimRight(indent+l, " ") + "\n")
+	}
+}
+
+func (r *terminal) inlines(inlines []Inline) string {
+	var sb strings.Builder
+	for _, in := range inlines {
+		switch in.Kind {
+		case Break:
+			sb.WriteString("\n")
+		case Mention:
+			sb.WriteString(r.style(bold, "@"+in.Text))
+		default:
+			sb.WriteString(r.text(in))
+		}
+	}
+	return sb.String()
+}
+
+func (r *terminal) text(in Inline) string {
+	s := in.Text
+	switch {
+	case in.Code:
+		s = r.style(cyan, s)
+	default:
+		var codes string
+		if in.Strong {
+			codes += bold
+		}
+		if in.Emphasis {
+			codes += italic
+		}
+		if in.Strike {
+			codes += strike
+		}
+		if codes != "" {
+			s = r.style(codes, s)
+		}
+	}
+	if in.URL != "" && in.URL != in.Text {
+		s += " (" + r.style(underline, in.URL) + ")"
+	} else if in.URL != "" {
+		s = r.style(underline, s)
+	}
+	return s
+}
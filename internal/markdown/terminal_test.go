@@ -0,0 +1,42 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package markdown
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Terminal", func() {
+	It("should render the blocks as plain text without color", func() {
+		src := "## Steps\n\n1. run `make`\n2. see **error**\n\n```\nexit 1\n```\n\n> quoted\n\nsee [docs](https://example.com) @jmrodri"
+		Expect(Terminal(src, false)).To(Equal("## Steps\n" +
+			"\n" +
+			"1. run make\n" +
+			"2. see error\n" +
+			"\n" +
+			"    exit 1\n" +
+			"\n" +
+			"│ quoted\n" +
+			"\n" +
+			"see docs (https://example.com) @jmrodri\n"))
+	})
+	It("should indent nested list content", func() {
+		Expect(Terminal("- one\n  - two\n", false)).To(Equal("• one\n  • two\n"))
+	})
+	It("should style inline text with color", func() {
+		Expect(Terminal("**bold** `code`", true)).To(Equal("\033[1mbold\033[0m \033[36mcode\033[0m\n"))
+	})
+})
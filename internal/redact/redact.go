@@ -0,0 +1,159 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package redact masks secrets, the tokens in use and Authorization headers,
+// in everything gh2jira prints.
+package redact
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// Mask replaces the secrets.
+	Mask = "[REDACTED]"
+
+	// minSecretLen keeps short values, likely placeholders, from masking
+	// every occurrence of a common word.
+	minSecretLen = 4
+)
+
+// authorization matches the value of an Authorization header in a dump of
+// the header, JSON or Go syntax.
+var authorization = regexp.MustCompile(
+	`(?i)(authorization"?'?\]?\s*[:=]\s*(?:\[\s*)?["']?)((?:bearer|basic|token)\s+)?[^\s"',\]]+`)
+
+var (
+	mu       sync.RWMutex
+	secrets  = map[string]bool{}
+	replacer = strings.NewReplacer()
+)
+
+// Add registers secrets to mask, e.g. the tokens once they are resolved.
+// Empty and very short values are ignored.
+func Add(values ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, v := range values {
+		if v = strings.TrimSpace(v); len(v) >= minSecretLen {
+			secrets[v] = true
+		}
+	}
+
+	// longest first so a secret containing another is masked whole
+	var sorted []string
+	for s := range secrets {
+		sorted = append(sorted, s)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i]) != len(sorted[j]) {
+			return len(sorted[i]) > len(sorted[j])
+		}
+		return sorted[i] < sorted[j]
+	})
+	var pairs []string
+	for _, s := range sorted {
+		pairs = append(pairs, s, Mask)
+	}
+	replacer = strings.NewReplacer(pairs...)
+}
+
+// Reset forgets the secrets added.
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	secrets = map[string]bool{}
+	replacer = strings.NewReplacer()
+}
+
+// String masks the secrets and Authorization header values in s.
+func String(s string) string {
+	mu.RLock()
+	r := replacer
+	mu.RUnlock()
+	s = r.Replace(s)
+	return authorization.ReplaceAllString(s, "${1}${2}"+Mask)
+}
+
+// Error returns err with the secrets masked in its message. It still wraps
+// err for errors.Is and errors.As.
+func Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &redactedError{err: err}
+}
+
+type redactedError struct {
+	err error
+}
+
+func (e *redactedError) Error() string {
+	return String(e.err.Error())
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// Writer returns a writer masking the secrets in everything written to w. A
+// secret split across two writes is not masked, so write whole lines.
+func Writer(w io.Writer) io.Writer {
+	return &writer{w: w}
+}
+
+type writer struct {
+	w io.Writer
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, String(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Stdout and Stderr mask the secrets written to the current os.Stdout and
+// os.Stderr.
+var (
+	Stdout = Writer(fileWriter(func() *os.File { return os.Stdout }))
+	Stderr = Writer(fileWriter(func() *os.File { return os.Stderr }))
+)
+
+type fileWriter func() *os.File
+
+func (f fileWriter) Write(p []byte) (int, error) {
+	return f().Write(p)
+}
+
+// Transport returns a round tripper masking the secrets in the errors of rt,
+// which may quote the request.
+func Transport(rt http.RoundTripper) http.RoundTripper {
+	return transport{rt: rt}
+}
+
+type transport struct {
+	rt http.RoundTripper
+}
+
+func (t transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.rt.RoundTrip(req)
+	return resp, Error(err)
+}
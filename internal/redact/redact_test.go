@@ -0,0 +1,109 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redact
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"net/http"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type failingTransport struct {
+	err error
+}
+
+func (t failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, t.err
+}
+
+var _ = Describe("Redact", func() {
+	BeforeEach(func() {
+		Reset()
+	})
+	AfterEach(func() {
+		Reset()
+	})
+	Describe("String", func() {
+		It("should mask the secrets added", func() {
+			Add("ghp_abcdef123456", "jira-token-xyz")
+			Expect(String("github ghp_abcdef123456, jira jira-token-xyz")).
+				To(Equal("github [REDACTED], jira [REDACTED]"))
+		})
+		It("should mask a secret containing another whole", func() {
+			Add("abcd", "abcdefgh")
+			Expect(String("token abcdefgh")).To(Equal("token [REDACTED]"))
+		})
+		It("should ignore empty and short secrets", func() {
+			Add("", "  ", "abc")
+			Expect(String("abc is not a secret")).To(Equal("abc is not a secret"))
+		})
+		It("should forget the secrets on Reset", func() {
+			Add("ghp_abcdef123456")
+			Reset()
+			Expect(String("ghp_abcdef123456")).To(Equal("ghp_abcdef123456"))
+		})
+		It("should mask Authorization headers", func() {
+			Expect(String("Authorization: Bearer unknown-token")).
+				To(Equal("Authorization: Bearer [REDACTED]"))
+			Expect(String("authorization: Basic amFuZTpzZWNyZXQ=")).
+				To(Equal("authorization: Basic [REDACTED]"))
+			Expect(String(`{"Authorization": "token unknown-token"}`)).
+				To(Equal(`{"Authorization": "token [REDACTED]"}`))
+			Expect(String(`map[Authorization:[Bearer unknown-token]]`)).
+				To(Equal(`map[Authorization:[Bearer [REDACTED]]]`))
+			Expect(String("Authorization: unknown-token")).
+				To(Equal("Authorization: [REDACTED]"))
+		})
+		It("should leave other text alone", func() {
+			Expect(String("Cloning issue #123 to jira project board: OSDK")).
+				To(Equal("Cloning issue #123 to jira project board: OSDK"))
+		})
+	})
+	Describe("Error", func() {
+		It("should return nil for no error", func() {
+			Expect(Error(nil)).To(BeNil())
+		})
+		It("should mask the secrets in the message and keep the error", func() {
+			Add("ghp_abcdef123456")
+			err := Error(&fs.PathError{Op: "open", Path: "ghp_abcdef123456", Err: fs.ErrNotExist})
+			Expect(err.Error()).To(Equal("open [REDACTED]: file does not exist"))
+			Expect(errors.Is(err, fs.ErrNotExist)).To(BeTrue())
+		})
+	})
+	Describe("Writer", func() {
+		It("should mask the secrets written", func() {
+			Add("ghp_abcdef123456")
+			var buf bytes.Buffer
+			n, err := Writer(&buf).Write([]byte("token ghp_abcdef123456\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(n).To(Equal(len("token ghp_abcdef123456\n")))
+			Expect(buf.String()).To(Equal("token [REDACTED]\n"))
+		})
+	})
+	Describe("Transport", func() {
+		It("should mask the secrets in the errors", func() {
+			Add("ghp_abcdef123456")
+			rt := Transport(failingTransport{err: errors.New("bad request with Bearer ghp_abcdef123456")})
+			req, _ := http.NewRequest(http.MethodGet, "http://localhost", nil)
+			_, err := rt.RoundTrip(req)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).NotTo(ContainSubstring("ghp_abcdef123456"))
+		})
+	})
+})
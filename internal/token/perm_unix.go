@@ -0,0 +1,40 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build unix
+
+package token
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// insecurePermissions tells what is wrong with the mode and owner of a token
+// file, empty if nothing is, and whether restricting the mode fixes it.
+func insecurePermissions(fi os.FileInfo) (string, bool) {
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok && int(st.Uid) != os.Getuid() {
+		return fmt.Sprintf("is owned by uid %d, not by you", st.Uid), false
+	}
+	switch perm := fi.Mode().Perm(); {
+	case perm&0o044 != 0:
+		return fmt.Sprintf("is readable by group or others (mode %04o)", perm), true
+	case perm&0o022 != 0:
+		return fmt.Sprintf("is writable by group or others (mode %04o)", perm), true
+	case perm&0o011 != 0:
+		return fmt.Sprintf("is executable by group or others (mode %04o)", perm), true
+	}
+	return "", false
+}
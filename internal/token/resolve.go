@@ -0,0 +1,359 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// Github and Jira name the tokens to resolve.
+	Github = "github"
+	Jira   = "jira"
+
+	// GithubTokenEnv and JiraTokenEnv are the environment variables holding
+	// the tokens.
+	GithubTokenEnv = "GITHUB_TOKEN"
+	JiraTokenEnv   = "JIRA_TOKEN"
+
+	// helperTimeout bounds how long a credential helper or token command
+	// may take.
+	helperTimeout = 30 * time.Second
+)
+
+type Option func(*ResolverConfig) error
+
+// ResolverConfig says where Resolve looks for tokens. The sources are tried
+// in this order: flags, environment variables, the configured source of the
+// token, the token file and the credential helper.
+type ResolverConfig struct {
+	githubFlag string
+	jiraFlag   string
+	sources    map[string]Source
+	files      []string
+	helper     string
+	required   []string
+
+	strict   bool
+	fixPerms bool
+	warnings io.Writer
+}
+
+// WithFlags sets the tokens given on the command line.
+func WithFlags(github, jira string) Option {
+	return func(c *ResolverConfig) error {
+		c.githubFlag = github
+		c.jiraFlag = jira
+		return nil
+	}
+}
+
+// WithSource sets where the Github or Jira token is configured to come
+// from, e.g. a command or the keyring.
+func WithSource(token string, source Source) Option {
+	return func(c *ResolverConfig) error {
+		if token != Github && token != Jira {
+			return fmt.Errorf("unknown token %q", token)
+		}
+		if c.sources == nil {
+			c.sources = map[string]Source{}
+		}
+		c.sources[token] = source
+		return nil
+	}
+}
+
+// WithFile sets the YAML token file, the first of the files that exists is
+// read. Missing files are skipped.
+func WithFile(files ...string) Option {
+	return func(c *ResolverConfig) error {
+		c.files = nil
+		for _, f := range files {
+			if f != "" {
+				c.files = append(c.files, f)
+			}
+		}
+		return nil
+	}
+}
+
+// WithStrictPermissions refuses token files group or others can access
+// instead of warning about them.
+func WithStrictPermissions(s bool) Option {
+	return func(c *ResolverConfig) error {
+		c.strict = s
+		return nil
+	}
+}
+
+// WithFixPermissions makes token files group or others can access private
+// to the user.
+func WithFixPermissions(f bool) Option {
+	return func(c *ResolverConfig) error {
+		c.fixPerms = f
+		return nil
+	}
+}
+
+// WithWarnings sets where warnings about token files go, stderr by default.
+func WithWarnings(w io.Writer) Option {
+	return func(c *ResolverConfig) error {
+		c.warnings = w
+		return nil
+	}
+}
+
+// WithHelper sets the credential helper command. It is run as
+// `<helper> get github` or `<helper> get jira` and must print the token. The
+// command is split into words like a shell would, quotes included.
+func WithHelper(helper string) Option {
+	return func(c *ResolverConfig) error {
+		c.helper = helper
+		return nil
+	}
+}
+
+// WithRequired sets which tokens must be found, Github and/or Jira. Both are
+// required by default.
+func WithRequired(names ...string) Option {
+	return func(c *ResolverConfig) error {
+		for _, n := range names {
+			if n != Github && n != Jira {
+				return fmt.Errorf("unknown token %q", n)
+			}
+		}
+		c.required = names
+		return nil
+	}
+}
+
+// source is one place a token can come from.
+type source struct {
+	// name describes the source once looked up
+	name   func() string
+	lookup func(token string) (string, error)
+	// namedErrors is set when the errors of lookup already name the source
+	namedErrors bool
+}
+
+// named returns the name of a source that does not change.
+func named(name string) func() string {
+	return func() string { return name }
+}
+
+// Resolve returns the tokens from the first source that has each of them.
+// The error of a missing token names every source that was tried.
+func Resolve(opts ...Option) (*Tokens, error) {
+	config := ResolverConfig{
+		required: []string{Github, Jira},
+		warnings: defaultWarnings,
+	}
+	for _, opt := range opts {
+		if err := opt(&config); err != nil {
+			return nil, err
+		}
+	}
+
+	// the token file is only read when a token is not found before it, so
+	// --strict does not refuse a file that would not be used
+	var (
+		fileTokens *Tokens
+		fileRead   bool
+		fileName   = "token file (none given)"
+	)
+	readFileTokens := func() error {
+		if fileRead || len(config.files) == 0 {
+			return nil
+		}
+		fileRead = true
+		tokens, file, err := config.readTokenFile()
+		if err != nil {
+			return err
+		}
+		fileTokens = tokens
+		if fileTokens == nil {
+			fileName = fmt.Sprintf("token file %s (not found)", strings.Join(config.files, " or "))
+		} else {
+			fileName = "token file " + file
+		}
+		return nil
+	}
+
+	flags := source{
+		name: named("--github-token/--jira-token flags"),
+		lookup: func(t string) (string, error) {
+			return pick(t, config.githubFlag, config.jiraFlag), nil
+		},
+	}
+	env := source{
+		name: named(fmt.Sprintf("%s/%s environment variables", GithubTokenEnv, JiraTokenEnv)),
+		lookup: func(t string) (string, error) {
+			return pick(t, os.Getenv(GithubTokenEnv), os.Getenv(JiraTokenEnv)), nil
+		},
+	}
+	file := source{
+		name: func() string { return fileName },
+		lookup: func(t string) (string, error) {
+			if err := readFileTokens(); err != nil {
+				return "", err
+			}
+			if fileTokens == nil {
+				return "", nil
+			}
+			return pick(t, fileTokens.GithubToken, fileTokens.JiraToken), nil
+		},
+		namedErrors: true,
+	}
+	helper := source{
+		name: named("credential helper"),
+		lookup: func(t string) (string, error) {
+			if config.helper == "" {
+				return "", nil
+			}
+			return runHelper(config.helper, t)
+		},
+	}
+	if config.helper == "" {
+		helper.name = named("credential helper (none configured)")
+	}
+
+	tokens := &Tokens{}
+	for _, t := range config.required {
+		var value string
+		var tried []string
+		// a source configured for the token comes before the token file
+		tokenSources := []source{flags, env}
+		if src := config.sources[t]; src != nil {
+			tokenSources = append(tokenSources, source{
+				name:   named(src.String()),
+				lookup: func(string) (string, error) { return src.Token() },
+			})
+		}
+		tokenSources = append(tokenSources, file, helper)
+		for _, s := range tokenSources {
+			v, err := s.lookup(t)
+			tried = append(tried, s.name())
+			if err != nil {
+				if s.namedErrors {
+					return nil, err
+				}
+				return nil, fmt.Errorf("%s token: %s: %w", t, s.name(), err)
+			}
+			if v != "" {
+				value = v
+				break
+			}
+		}
+		if value == "" {
+			return nil, fmt.Errorf("missing required %s token, tried: %s", t, strings.Join(tried, ", "))
+		}
+		if t == Github {
+			tokens.GithubToken = value
+		} else {
+			tokens.JiraToken = value
+		}
+	}
+	return tokens, nil
+}
+
+func pick(token, github, jira string) string {
+	if token == Github {
+		return strings.TrimSpace(github)
+	}
+	return strings.TrimSpace(jira)
+}
+
+// readTokenFile reads the first token file that exists, nil if none does.
+func (c *ResolverConfig) readTokenFile() (*Tokens, string, error) {
+	for _, file := range c.files {
+		data, err := c.readPrivateFile(file)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		var tokens Tokens
+		if err := yaml.Unmarshal(data, &tokens); err != nil {
+			return nil, "", fmt.Errorf("invalid token file %s: %w", file, err)
+		}
+		return &tokens, file, nil
+	}
+	return nil, "", nil
+}
+
+// readPrivateFile reads the file once its permissions are checked. The open
+// file is checked, so the file read is the file checked.
+func (c *ResolverConfig) readPrivateFile(file string) ([]byte, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkPermissions(f, fi); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(f)
+}
+
+// checkPermissions warns about, fixes or refuses a token file others can
+// read, depending on the options.
+func (c *ResolverConfig) checkPermissions(f *os.File, fi os.FileInfo) error {
+	file := f.Name()
+	problem, chmodFixes := insecurePermissions(fi)
+	if problem == "" {
+		return nil
+	}
+	if c.fixPerms && chmodFixes {
+		if err := f.Chmod(0o600); err != nil {
+			return fmt.Errorf("unable to fix the permissions of token file %s: %w", file, err)
+		}
+		fmt.Fprintf(c.warnings, "Fixed the permissions of token file %s, only you can read it now\n", file)
+		return nil
+	}
+
+	advice := "make sure only you can read it"
+	if chmodFixes {
+		advice = fmt.Sprintf("run chmod 600 %s or use --fix-perms", file)
+	}
+	if c.strict {
+		return fmt.Errorf("token file %s %s, %s", file, problem, advice)
+	}
+	fmt.Fprintf(c.warnings, "Warning: token file %s %s, %s\n", file, problem, advice)
+	return nil
+}
+
+// runHelper runs the credential helper for the given token.
+func runHelper(helper, token string) (string, error) {
+	args, err := splitWords(helper)
+	if err != nil {
+		return "", err
+	}
+	if len(args) == 0 {
+		return "", nil
+	}
+	return runCommand(append(args, "get", token))
+}
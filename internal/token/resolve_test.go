@@ -0,0 +1,194 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Resolve", func() {
+	var (
+		dir       string
+		tokenFile string
+		saved     map[string]string
+	)
+	BeforeEach(func() {
+		saved = map[string]string{}
+		for _, env := range []string{GithubTokenEnv, JiraTokenEnv} {
+			if v, ok := os.LookupEnv(env); ok {
+				saved[env] = v
+			}
+			os.Unsetenv(env)
+		}
+
+		var err error
+		dir, err = os.MkdirTemp("", "token")
+		Expect(err).NotTo(HaveOccurred())
+		tokenFile = filepath.Join(dir, "tokens.yaml")
+		Expect(os.WriteFile(tokenFile, []byte("githubToken: file-gh\njiraToken: file-jira\n"), 0600)).To(Succeed())
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+		for _, env := range []string{GithubTokenEnv, JiraTokenEnv} {
+			os.Unsetenv(env)
+			if v, ok := saved[env]; ok {
+				os.Setenv(env, v)
+			}
+		}
+	})
+
+	// helper writes a fake credential helper printing the token name
+	helper := func(script string) string {
+		path := filepath.Join(dir, "helper")
+		Expect(os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0700)).To(Succeed())
+		return path
+	}
+
+	It("should prefer flags over everything", func() {
+		os.Setenv(GithubTokenEnv, "env-gh")
+		tokens, err := Resolve(WithFlags("flag-gh", ""), WithFile(tokenFile))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tokens.GithubToken).To(Equal("flag-gh"))
+		Expect(tokens.JiraToken).To(Equal("file-jira"))
+	})
+	It("should prefer the environment over the file", func() {
+		os.Setenv(JiraTokenEnv, "env-jira")
+		tokens, err := Resolve(WithFile(tokenFile))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tokens.GithubToken).To(Equal("file-gh"))
+		Expect(tokens.JiraToken).To(Equal("env-jira"))
+	})
+	It("should work without a token file", func() {
+		os.Setenv(GithubTokenEnv, "env-gh")
+		os.Setenv(JiraTokenEnv, "env-jira")
+		tokens, err := Resolve(WithFile(filepath.Join(dir, "missing.yaml")))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tokens.GithubToken).To(Equal("env-gh"))
+		Expect(tokens.JiraToken).To(Equal("env-jira"))
+	})
+	It("should fall back to the credential helper", func() {
+		tokens, err := Resolve(WithHelper(helper(`echo "helper-$2"`)))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tokens.GithubToken).To(Equal("helper-github"))
+		Expect(tokens.JiraToken).To(Equal("helper-jira"))
+	})
+	It("should return the error of a failing credential helper", func() {
+		_, err := Resolve(WithHelper(helper("exit 3")))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("github token: credential helper: exit status 3"))
+	})
+	It("should only require the tokens asked for", func() {
+		os.Setenv(GithubTokenEnv, "env-gh")
+		tokens, err := Resolve(WithRequired(Github))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tokens.GithubToken).To(Equal("env-gh"))
+		Expect(tokens.JiraToken).To(BeEmpty())
+	})
+	It("should name every source tried", func() {
+		_, err := Resolve(WithFile(filepath.Join(dir, "missing.yaml")), WithRequired(Jira))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("missing required jira token, tried: --github-token/--jira-token flags, " +
+			"GITHUB_TOKEN/JIRA_TOKEN environment variables, token file " +
+			filepath.Join(dir, "missing.yaml") + " (not found), credential helper (none configured)"))
+	})
+	It("should fail on a broken token file", func() {
+		Expect(os.WriteFile(tokenFile, []byte("githubToken= foo"), 0600)).To(Succeed())
+		_, err := Resolve(WithFile(tokenFile))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("invalid token file"))
+	})
+	It("should reject unknown tokens", func() {
+		_, err := Resolve(WithRequired("gitlab"))
+		Expect(err).To(HaveOccurred())
+	})
+	It("should read the first token file that exists", func() {
+		tokens, err := Resolve(WithFile(filepath.Join(dir, "missing.yaml"), tokenFile))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tokens.GithubToken).To(Equal("file-gh"))
+	})
+	It("should name every token file searched", func() {
+		_, err := Resolve(WithFile("a.yaml", "b.yaml"), WithRequired(Github))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("token file a.yaml or b.yaml (not found)"))
+	})
+
+	Describe("token file permissions", func() {
+		var warnings *bytes.Buffer
+		BeforeEach(func() {
+			warnings = &bytes.Buffer{}
+			Expect(os.Chmod(tokenFile, 0644)).To(Succeed())
+		})
+
+		It("should not warn about a private file", func() {
+			Expect(os.Chmod(tokenFile, 0600)).To(Succeed())
+			_, err := Resolve(WithFile(tokenFile), WithWarnings(warnings))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings.String()).To(BeEmpty())
+		})
+		It("should warn about a file others can read", func() {
+			tokens, err := Resolve(WithFile(tokenFile), WithWarnings(warnings))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tokens.GithubToken).To(Equal("file-gh"))
+			Expect(warnings.String()).To(Equal("Warning: token file " + tokenFile +
+				" is readable by group or others (mode 0644), run chmod 600 " + tokenFile + " or use --fix-perms\n"))
+		})
+		It("should warn about a file others can write", func() {
+			Expect(os.Chmod(tokenFile, 0620)).To(Succeed())
+			_, err := Resolve(WithFile(tokenFile), WithWarnings(warnings))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings.String()).To(Equal("Warning: token file " + tokenFile +
+				" is writable by group or others (mode 0620), run chmod 600 " + tokenFile + " or use --fix-perms\n"))
+		})
+		It("should refuse a file others can read if strict", func() {
+			_, err := Resolve(WithFile(tokenFile), WithWarnings(warnings), WithStrictPermissions(true))
+			Expect(err).To(MatchError("token file " + tokenFile +
+				" is readable by group or others (mode 0644), run chmod 600 " + tokenFile + " or use --fix-perms"))
+		})
+		It("should fix the permissions if asked to", func() {
+			_, err := Resolve(WithFile(tokenFile), WithWarnings(warnings),
+				WithStrictPermissions(true), WithFixPermissions(true))
+			Expect(err).NotTo(HaveOccurred())
+			fi, err := os.Stat(tokenFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fi.Mode().Perm()).To(Equal(os.FileMode(0600)))
+			Expect(warnings.String()).To(ContainSubstring("Fixed the permissions of token file"))
+		})
+		It("should not read the file if it is not needed", func() {
+			os.Setenv(GithubTokenEnv, "env-gh")
+			tokens, err := Resolve(WithFlags("", "flag-jira"), WithFile(tokenFile),
+				WithWarnings(warnings), WithStrictPermissions(true))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tokens.GithubToken).To(Equal("env-gh"))
+			Expect(tokens.JiraToken).To(Equal("flag-jira"))
+			Expect(warnings.String()).To(BeEmpty())
+		})
+		It("should warn about a file others can read in ReadTokensYaml", func() {
+			saved := defaultWarnings
+			defer func() { defaultWarnings = saved }()
+			defaultWarnings = warnings
+			readFile = readPrivateFile
+
+			tokens, err := ReadTokensYaml(tokenFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tokens.GithubToken).To(Equal("file-gh"))
+			Expect(warnings.String()).To(HavePrefix("Warning: token file " + tokenFile + " is readable by group or others"))
+		})
+	})
+})
@@ -0,0 +1,305 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"unicode"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// KeyringService is the service attribute of the tokens stored in the
+	// keyring, their account attribute is configured per token.
+	KeyringService = "gh2jira"
+
+	// PassphraseEnv is the environment variable holding the passphrase of
+	// encrypted token files.
+	PassphraseEnv = "GH2JIRA_PASSPHRASE"
+
+	encryptedHeader = "gh2jira-encrypted-v1"
+	saltSize        = 16
+	nonceSize       = 24
+)
+
+// Source is a place a single token is read from.
+type Source interface {
+	// Token returns the token, empty if the source does not have it.
+	Token() (string, error)
+	// String describes the source in errors.
+	String() string
+}
+
+// SourceConfig configures where a token comes from, exactly one of its
+// fields is set.
+type SourceConfig struct {
+	// Command prints the token, e.g. `gh auth token` or `pass show jira`.
+	// It is split into words like a shell would, so an argument with spaces
+	// can be quoted: `pass show "jira token"`. It is not run by a shell.
+	Command string `yaml:"command,omitempty"`
+	// Keyring is the account of the token in the Secret Service keyring.
+	Keyring string `yaml:"keyring,omitempty"`
+	// EncryptedFile is a file written by Encrypt, decrypted with the
+	// passphrase in $GH2JIRA_PASSPHRASE.
+	EncryptedFile string `yaml:"encryptedFile,omitempty"`
+}
+
+// Source returns the configured source.
+func (c *SourceConfig) Source() (Source, error) {
+	var sources []Source
+	if c.Command != "" {
+		sources = append(sources, &commandSource{command: c.Command})
+	}
+	if c.Keyring != "" {
+		sources = append(sources, &keyringSource{account: c.Keyring})
+	}
+	if c.EncryptedFile != "" {
+		sources = append(sources, &encryptedFileSource{file: c.EncryptedFile})
+	}
+	if len(sources) != 1 {
+		return nil, errors.New("a token source must set exactly one of command, keyring or encryptedFile")
+	}
+	return sources[0], nil
+}
+
+type commandSource struct {
+	command string
+}
+
+func (s *commandSource) Token() (string, error) {
+	args, err := splitWords(s.command)
+	if err != nil {
+		return "", err
+	}
+	return runCommand(args)
+}
+
+func (s *commandSource) String() string {
+	return fmt.Sprintf("command %q", s.command)
+}
+
+// splitWords splits a command into its words like a shell would, minus the
+// expansions: single quotes keep everything, double quotes keep everything but
+// backslash escapes, and a backslash outside quotes escapes the next
+// character.
+func splitWords(command string) ([]string, error) {
+	var (
+		words   []string
+		word    strings.Builder
+		inWord  bool
+		quote   rune
+		escaped bool
+	)
+	for _, r := range command {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case unicode.IsSpace(r):
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if escaped || quote != 0 {
+		return nil, fmt.Errorf("unterminated quote or escape in command %q", command)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// runCommand runs the command and returns what it printed.
+func runCommand(args []string) (string, error) {
+	if len(args) == 0 {
+		return "", nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), helperTimeout)
+	defer cancel()
+
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout = &stdout
+	// let the command prompt or explain itself
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// Keyring looks up secrets by service and account.
+type Keyring interface {
+	// Get returns the secret, empty if there is none.
+	Get(service, account string) (string, error)
+}
+
+// secretTool reads the Secret Service keyring, e.g. GNOME Keyring or KWallet,
+// with secret-tool of libsecret.
+type secretTool struct{}
+
+// overrideable func for mocking the secret-tool command
+var secretToolCommand = func(args ...string) *exec.Cmd {
+	return exec.Command("secret-tool", args...)
+}
+
+func (secretTool) Get(service, account string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := secretToolCommand("lookup", "service", service, "account", account)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		msg := strings.TrimSpace(stderr.String())
+		if exitErr.ExitCode() == 1 && stdout.Len() == 0 && msg == "" {
+			// secret-tool fails silently if there is no such secret
+			return "", nil
+		}
+		// e.g. a locked keyring or no D-Bus session
+		if msg != "" {
+			return "", fmt.Errorf("secret-tool: %w: %s", err, msg)
+		}
+		return "", fmt.Errorf("secret-tool: %w", err)
+	}
+	if errors.Is(err, exec.ErrNotFound) {
+		return "", errors.New("secret-tool not found, install libsecret-tools")
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// overrideable keyring for testing
+var keyring Keyring = secretTool{}
+
+type keyringSource struct {
+	account string
+}
+
+func (s *keyringSource) Token() (string, error) {
+	return keyring.Get(KeyringService, s.account)
+}
+
+func (s *keyringSource) String() string {
+	return fmt.Sprintf("keyring service %s account %s", KeyringService, s.account)
+}
+
+type encryptedFileSource struct {
+	file string
+}
+
+func (s *encryptedFileSource) Token() (string, error) {
+	data, err := os.ReadFile(s.file)
+	if err != nil {
+		return "", err
+	}
+	passphrase := os.Getenv(PassphraseEnv)
+	if passphrase == "" {
+		return "", fmt.Errorf("%s is not set", PassphraseEnv)
+	}
+	return Decrypt(data, passphrase)
+}
+
+func (s *encryptedFileSource) String() string {
+	return "encrypted file " + s.file
+}
+
+// Encrypt encrypts the token with a key derived from the passphrase. The
+// result is the content of an encrypted token file.
+func Encrypt(token, passphrase string) ([]byte, error) {
+	var salt [saltSize]byte
+	var nonce [nonceSize]byte
+	if _, err := rand.Read(salt[:]); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+	key, err := deriveKey(passphrase, salt[:])
+	if err != nil {
+		return nil, err
+	}
+
+	sealed := append(salt[:], nonce[:]...)
+	sealed = secretbox.Seal(sealed, []byte(token), &nonce, key)
+	return []byte(encryptedHeader + "\n" + base64.StdEncoding.EncodeToString(sealed) + "\n"), nil
+}
+
+// Decrypt returns the token of an encrypted token file.
+func Decrypt(data []byte, passphrase string) (string, error) {
+	header, encoded, _ := strings.Cut(string(data), "\n")
+	if header != encryptedHeader {
+		return "", errors.New("not an encrypted token file")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted token file: %w", err)
+	}
+	if len(sealed) < saltSize+nonceSize+secretbox.Overhead {
+		return "", errors.New("invalid encrypted token file: too short")
+	}
+
+	var nonce [nonceSize]byte
+	copy(nonce[:], sealed[saltSize:saltSize+nonceSize])
+	key, err := deriveKey(passphrase, sealed[:saltSize])
+	if err != nil {
+		return "", err
+	}
+	token, ok := secretbox.Open(nil, sealed[saltSize+nonceSize:], &nonce, key)
+	if !ok {
+		return "", errors.New("unable to decrypt the token, wrong passphrase?")
+	}
+	return strings.TrimSpace(string(token)), nil
+}
+
+func deriveKey(passphrase string, salt []byte) (*[32]byte, error) {
+	k, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	var key [32]byte
+	copy(key[:], k)
+	return &key, nil
+}
@@ -0,0 +1,192 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package token
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// memoryKeyring is a keyring backend for testing
+type memoryKeyring map[string]string
+
+func (k memoryKeyring) Get(service, account string) (string, error) {
+	return k[service+"/"+account], nil
+}
+
+var _ = Describe("Source", func() {
+	var (
+		dir     string
+		saved   map[string]string
+		envVars = []string{GithubTokenEnv, JiraTokenEnv, PassphraseEnv}
+	)
+	BeforeEach(func() {
+		keyring = memoryKeyring{"gh2jira/jira": "keyring-jira"}
+
+		saved = map[string]string{}
+		for _, env := range envVars {
+			if v, ok := os.LookupEnv(env); ok {
+				saved[env] = v
+			}
+			os.Unsetenv(env)
+		}
+
+		var err error
+		dir, err = os.MkdirTemp("", "source")
+		Expect(err).NotTo(HaveOccurred())
+	})
+	AfterEach(func() {
+		keyring = secretTool{}
+		os.RemoveAll(dir)
+		for _, env := range envVars {
+			os.Unsetenv(env)
+			if v, ok := saved[env]; ok {
+				os.Setenv(env, v)
+			}
+		}
+	})
+
+	source := func(c SourceConfig) Source {
+		s, err := c.Source()
+		Expect(err).NotTo(HaveOccurred())
+		return s
+	}
+
+	Describe("SourceConfig", func() {
+		It("should require exactly one source", func() {
+			_, err := (&SourceConfig{}).Source()
+			Expect(err).To(HaveOccurred())
+			_, err = (&SourceConfig{Command: "gh auth token", Keyring: "github"}).Source()
+			Expect(err).To(MatchError("a token source must set exactly one of command, keyring or encryptedFile"))
+		})
+	})
+
+	Describe("command", func() {
+		It("should return what the command prints", func() {
+			path := filepath.Join(dir, "gh")
+			Expect(os.WriteFile(path, []byte("#!/bin/sh\necho \"  token-of-$1  \"\n"), 0700)).To(Succeed())
+			s := source(SourceConfig{Command: path + " auth token"})
+			Expect(s.Token()).To(Equal("token-of-auth"))
+			Expect(s.String()).To(Equal(`command "` + path + ` auth token"`))
+		})
+		It("should return the error of a failing command", func() {
+			s := source(SourceConfig{Command: "false"})
+			_, err := s.Token()
+			Expect(err).To(MatchError("exit status 1"))
+		})
+		It("should keep quoted arguments together", func() {
+			path := filepath.Join(dir, "pass")
+			Expect(os.WriteFile(path, []byte("#!/bin/sh\necho \"$#:$2\"\n"), 0700)).To(Succeed())
+			s := source(SourceConfig{Command: path + ` show "jira token"`})
+			Expect(s.Token()).To(Equal("2:jira token"))
+		})
+		It("should split words like a shell", func() {
+			Expect(splitWords(` a  'b c' "d \"e\"" f\ g ''`)).To(Equal([]string{"a", "b c", `d "e"`, "f g", ""}))
+			_, err := splitWords(`pass show "jira`)
+			Expect(err).To(MatchError(`unterminated quote or escape in command "pass show \"jira"`))
+		})
+	})
+
+	Describe("secret-tool", func() {
+		saved := secretToolCommand
+		AfterEach(func() {
+			secretToolCommand = saved
+		})
+		// fake runs the script instead of secret-tool
+		fake := func(script string) {
+			secretToolCommand = func(args ...string) *exec.Cmd {
+				return exec.Command("sh", "-c", script)
+			}
+		}
+
+		It("should return the secret", func() {
+			fake("echo ' s3cret '")
+			Expect(secretTool{}.Get(KeyringService, "jira")).To(Equal("s3cret"))
+		})
+		It("should return nothing if there is no such secret", func() {
+			fake("exit 1")
+			Expect(secretTool{}.Get(KeyringService, "jira")).To(BeEmpty())
+		})
+		It("should return why the keyring cannot be read", func() {
+			fake("echo 'Cannot autolaunch D-Bus without X11 $DISPLAY' >&2; exit 1")
+			_, err := secretTool{}.Get(KeyringService, "jira")
+			Expect(err).To(MatchError("secret-tool: exit status 1: Cannot autolaunch D-Bus without X11 $DISPLAY"))
+
+			fake("exit 2")
+			_, err = secretTool{}.Get(KeyringService, "jira")
+			Expect(err).To(MatchError("secret-tool: exit status 2"))
+		})
+	})
+
+	Describe("keyring", func() {
+		It("should look up the account", func() {
+			Expect(source(SourceConfig{Keyring: "jira"}).Token()).To(Equal("keyring-jira"))
+			Expect(source(SourceConfig{Keyring: "github"}).Token()).To(BeEmpty())
+		})
+	})
+
+	Describe("encrypted file", func() {
+		var file string
+		BeforeEach(func() {
+			data, err := Encrypt("secret-token", "correct horse")
+			Expect(err).NotTo(HaveOccurred())
+			file = filepath.Join(dir, "jira.enc")
+			Expect(os.WriteFile(file, data, 0600)).To(Succeed())
+		})
+		It("should decrypt the token with the passphrase", func() {
+			os.Setenv(PassphraseEnv, "correct horse")
+			Expect(source(SourceConfig{EncryptedFile: file}).Token()).To(Equal("secret-token"))
+		})
+		It("should fail with the wrong passphrase", func() {
+			os.Setenv(PassphraseEnv, "battery staple")
+			_, err := source(SourceConfig{EncryptedFile: file}).Token()
+			Expect(err).To(MatchError("unable to decrypt the token, wrong passphrase?"))
+		})
+		It("should require the passphrase", func() {
+			_, err := source(SourceConfig{EncryptedFile: file}).Token()
+			Expect(err).To(MatchError("GH2JIRA_PASSPHRASE is not set"))
+		})
+		It("should reject other files", func() {
+			_, err := Decrypt([]byte("githubToken: foo\n"), "correct horse")
+			Expect(err).To(MatchError("not an encrypted token file"))
+		})
+	})
+
+	Describe("Resolve", func() {
+		It("should try the configured source after the environment", func() {
+			tokenFile := filepath.Join(dir, "tokens.yaml")
+			Expect(os.WriteFile(tokenFile, []byte("githubToken: file-gh\njiraToken: file-jira\n"), 0600)).To(Succeed())
+			os.Setenv(GithubTokenEnv, "env-gh")
+
+			tokens, err := Resolve(
+				WithSource(Github, source(SourceConfig{Keyring: "github"})),
+				WithSource(Jira, source(SourceConfig{Keyring: "jira"})),
+				WithFile(tokenFile),
+			)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tokens.GithubToken).To(Equal("env-gh"))
+			Expect(tokens.JiraToken).To(Equal("keyring-jira"))
+		})
+		It("should name the configured source", func() {
+			_, err := Resolve(WithSource(Github, source(SourceConfig{Keyring: "github"})), WithRequired(Github))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("environment variables, keyring service gh2jira account github, token file"))
+		})
+	})
+})
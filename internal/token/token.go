@@ -14,6 +14,7 @@ package token
 
 import (
 	"errors"
+	"io"
 	"os"
 
 	"gopkg.in/yaml.v3"
@@ -24,6 +25,11 @@ type Tokens struct {
 	JiraToken   string `yaml:"jiraToken"`
 }
 
+// ReadTokensYaml reads both tokens from the YAML file. Like Resolve it warns
+// about a file group or others can access.
+//
+// Deprecated: use Resolve, which also looks at the flags, the environment and
+// the credential helper.
 func ReadTokensYaml(file string) (*Tokens, error) {
 	data, err := readFile(file)
 	if err != nil {
@@ -43,7 +49,15 @@ func ReadTokensYaml(file string) (*Tokens, error) {
 	return &tokens, nil
 }
 
-// overrideable func for mocking os.ReadFile
-var readFile = func(file string) ([]byte, error) {
-	return os.ReadFile(file)
+// overrideable func for mocking the reading of the token file
+var readFile = readPrivateFile
+
+// defaultWarnings is where warnings about token files go unless told
+// otherwise.
+var defaultWarnings io.Writer = os.Stderr
+
+// readPrivateFile reads the token file, warning if group or others can access it.
+func readPrivateFile(file string) ([]byte, error) {
+	c := ResolverConfig{warnings: defaultWarnings}
+	return c.readPrivateFile(file)
 }
@@ -0,0 +1,114 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/jmrodri/gh2jira/internal/redact"
+)
+
+// Config describes how to reach a service: which CAs to trust, the client
+// certificate to present and the proxy to go through.
+type Config struct {
+	// Name of the service, used in errors and warnings
+	Name string
+	// CABundle is a PEM file of CAs trusted in addition to the system ones
+	CABundle string
+	// ClientCert and ClientKey are PEM files used for mutual TLS
+	ClientCert string
+	ClientKey  string
+	// Proxy is the URL of the proxy to use, if empty the HTTPS_PROXY,
+	// HTTP_PROXY and NO_PROXY environment variables are honored
+	Proxy string
+	// InsecureSkipVerify disables verification of the server certificate
+	InsecureSkipVerify bool
+}
+
+// Warnings is where the warning about disabled certificate verification is
+// written.
+var Warnings io.Writer = redact.Stderr
+
+// Transport returns an http.Transport configured according to c.
+func (c *Config) Transport() (*http.Transport, error) {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if c == nil {
+		return t, nil
+	}
+
+	if c.Proxy != "" {
+		u, err := url.Parse(c.Proxy)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("invalid proxy url %q for %s", c.Proxy, c.name())
+		}
+		t.Proxy = http.ProxyURL(u)
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if c.CABundle != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		pem, err := os.ReadFile(c.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA bundle: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", c.CABundle)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if c.ClientCert != "" || c.ClientKey != "" {
+		if c.ClientCert == "" || c.ClientKey == "" {
+			return nil, errors.New("a client certificate requires both a certificate and a key")
+		}
+		cert, err := tls.LoadX509KeyPair(c.ClientCert, c.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if c.InsecureSkipVerify {
+		fmt.Fprintf(Warnings, "\n"+
+			"**************************************************************\n"+
+			"* WARNING: TLS certificate verification is DISABLED for %s.\n"+
+			"* Anyone on the network path can read and modify the traffic,\n"+
+			"* including your tokens. Use --ca-bundle instead if you can.\n"+
+			"**************************************************************\n\n",
+			c.name())
+		tlsConfig.InsecureSkipVerify = true // #nosec G402 -- explicitly requested
+	}
+
+	t.TLSClientConfig = tlsConfig
+	return t, nil
+}
+
+func (c *Config) name() string {
+	if c.Name == "" {
+		return "this connection"
+	}
+	return c.Name
+}
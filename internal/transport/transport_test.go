@@ -0,0 +1,203 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transport
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// writeCert creates a self signed certificate and writes the cert and key to
+// dir/name.crt and dir/name.key
+func writeCert(dir, name string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage: []x509.ExtKeyUsage{
+			x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth,
+		},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	Expect(err).NotTo(HaveOccurred())
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	Expect(err).NotTo(HaveOccurred())
+
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	Expect(os.WriteFile(certFile,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)).To(Succeed())
+	Expect(os.WriteFile(keyFile,
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600)).To(Succeed())
+	return certFile, keyFile
+}
+
+func get(t *http.Transport, u string) error {
+	resp, err := (&http.Client{Transport: t}).Get(u)
+	if err == nil {
+		resp.Body.Close()
+	}
+	return err
+}
+
+var _ = Describe("Transport", func() {
+	var (
+		dir                   string
+		server                *httptest.Server
+		serverCA              string
+		clientCA              string
+		clientCert, clientKey string
+	)
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "transport")
+		Expect(err).NotTo(HaveOccurred())
+
+		var serverKey string
+		serverCA, serverKey = writeCert(dir, "server")
+		clientCert, clientKey = writeCert(dir, "client")
+		clientCA = clientCert
+
+		cert, err := tls.LoadX509KeyPair(serverCA, serverKey)
+		Expect(err).NotTo(HaveOccurred())
+		server = httptest.NewUnstartedServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {}))
+		server.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+		server.StartTLS()
+	})
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(dir)
+	})
+
+	It("should return a default transport for a nil config", func() {
+		var c *Config
+		t, err := c.Transport()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(t).NotTo(BeNil())
+	})
+
+	It("should not trust unknown CAs by default", func() {
+		t, err := (&Config{}).Transport()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(get(t, server.URL)).NotTo(Succeed())
+	})
+
+	It("should trust the CA bundle", func() {
+		t, err := (&Config{CABundle: serverCA}).Transport()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(get(t, server.URL)).To(Succeed())
+	})
+
+	It("should return an error for a missing or empty CA bundle", func() {
+		_, err := (&Config{CABundle: filepath.Join(dir, "nope.pem")}).Transport()
+		Expect(err).To(HaveOccurred())
+
+		empty := filepath.Join(dir, "empty.pem")
+		Expect(os.WriteFile(empty, []byte("nothing"), 0o600)).To(Succeed())
+		_, err = (&Config{CABundle: empty}).Transport()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("no certificates found"))
+	})
+
+	It("should present the client certificate", func() {
+		server.Close()
+		pool := x509.NewCertPool()
+		pem, err := os.ReadFile(clientCA)
+		Expect(err).NotTo(HaveOccurred())
+		pool.AppendCertsFromPEM(pem)
+
+		cert := server.TLS.Certificates
+		server = httptest.NewUnstartedServer(http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {}))
+		server.TLS = &tls.Config{
+			Certificates: cert,
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			ClientCAs:    pool,
+		}
+		server.StartTLS()
+
+		t, err := (&Config{CABundle: serverCA}).Transport()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(get(t, server.URL)).NotTo(Succeed())
+
+		t, err = (&Config{CABundle: serverCA, ClientCert: clientCert,
+			ClientKey: clientKey}).Transport()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(get(t, server.URL)).To(Succeed())
+	})
+
+	It("should require both client certificate and key", func() {
+		_, err := (&Config{ClientCert: clientCert}).Transport()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("both a certificate and a key"))
+	})
+
+	It("should skip verification loudly when asked to", func() {
+		var warnings bytes.Buffer
+		tmp := Warnings
+		Warnings = &warnings
+		defer func() {
+			Warnings = tmp
+		}()
+
+		t, err := (&Config{Name: "jira", InsecureSkipVerify: true}).Transport()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(get(t, server.URL)).To(Succeed())
+		Expect(warnings.String()).To(ContainSubstring(
+			"WARNING: TLS certificate verification is DISABLED for jira"))
+	})
+
+	It("should use the given proxy", func() {
+		t, err := (&Config{Proxy: "http://proxy.example.com:3128"}).Transport()
+		Expect(err).NotTo(HaveOccurred())
+		req, _ := http.NewRequest("GET", "https://issues.redhat.com", nil)
+		u, err := t.Proxy(req)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(u).To(Equal(&url.URL{Scheme: "http", Host: "proxy.example.com:3128"}))
+	})
+
+	It("should reject invalid proxy urls", func() {
+		_, err := (&Config{Name: "github", Proxy: "not a url"}).Transport()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("invalid proxy url \"not a url\" for github"))
+	})
+})
@@ -0,0 +1,124 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/google/go-github/v47/github"
+)
+
+// Rule decides whether an issues event should clone the issue. Action is the
+// event action and Pattern a glob matched against what the action is about:
+//
+//	labeled=jira/clone    the issue was labeled jira/clone
+//	milestoned=v1.*       the issue was added to a milestone titled v1.*
+//	opened=kind/bug       the issue was opened with the kind/bug label
+//	opened                any issue was opened
+type Rule struct {
+	Action  string
+	Pattern string
+}
+
+var supportedActions = []string{"labeled", "milestoned", "opened", "reopened"}
+
+// ParseRule parses a rule in the ACTION[=PATTERN] form.
+func ParseRule(s string) (Rule, error) {
+	action, pattern, _ := strings.Cut(s, "=")
+	rule := Rule{Action: strings.TrimSpace(action), Pattern: strings.TrimSpace(pattern)}
+
+	supported := false
+	for _, a := range supportedActions {
+		if rule.Action == a {
+			supported = true
+		}
+	}
+	if !supported {
+		return rule, fmt.Errorf("invalid rule %q: action must be one of %s",
+			s, strings.Join(supportedActions, ", "))
+	}
+	if _, err := path.Match(rule.Pattern, ""); err != nil {
+		return rule, fmt.Errorf("invalid rule %q: %v", s, err)
+	}
+	if rule.Pattern == "" && (rule.Action == "labeled" || rule.Action == "milestoned") {
+		return rule, fmt.Errorf("invalid rule %q: %s requires a pattern", s, rule.Action)
+	}
+	return rule, nil
+}
+
+// ParseRules parses each of the given rules.
+func ParseRules(rules []string) ([]Rule, error) {
+	var parsed []Rule
+	for _, s := range rules {
+		rule, err := ParseRule(s)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, rule)
+	}
+	return parsed, nil
+}
+
+// String returns the rule in the form accepted by ParseRule.
+func (r Rule) String() string {
+	if r.Pattern == "" {
+		return r.Action
+	}
+	return r.Action + "=" + r.Pattern
+}
+
+// Match returns true if the rule applies to the given event.
+func (r Rule) Match(event *github.IssuesEvent) bool {
+	if event.GetAction() != r.Action {
+		return false
+	}
+
+	switch r.Action {
+	case "labeled":
+		return match(r.Pattern, event.GetLabel().GetName())
+	case "milestoned":
+		return match(r.Pattern, event.GetIssue().GetMilestone().GetTitle())
+	default:
+		if r.Pattern == "" {
+			return true
+		}
+		for _, l := range event.GetIssue().Labels {
+			if match(r.Pattern, l.GetName()) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// matchRules returns the first rule matching event.
+func matchRules(rules []Rule, event *github.IssuesEvent) (Rule, bool) {
+	for _, r := range rules {
+		if r.Match(event) {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+func match(pattern, s string) bool {
+	if s == "" {
+		return false
+	}
+	ok, _ := path.Match(pattern, s)
+	return ok
+}
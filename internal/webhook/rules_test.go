@@ -0,0 +1,83 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"github.com/google/go-github/v47/github"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Rules", func() {
+	Describe("ParseRule", func() {
+		It("should parse action and pattern", func() {
+			rule, err := ParseRule("labeled=jira/clone")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rule).To(Equal(Rule{Action: "labeled", Pattern: "jira/clone"}))
+			Expect(rule.String()).To(Equal("labeled=jira/clone"))
+		})
+		It("should allow opened without a pattern", func() {
+			rule, err := ParseRule("opened")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rule.String()).To(Equal("opened"))
+		})
+		It("should reject unsupported actions", func() {
+			_, err := ParseRule("closed=foo")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("action must be one of"))
+		})
+		It("should require a pattern for labeled and milestoned", func() {
+			_, err := ParseRule("milestoned")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("requires a pattern"))
+		})
+		It("should reject bad patterns", func() {
+			_, err := ParseRules([]string{"labeled=foo", "labeled=[bad"})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Match", func() {
+		var event *github.IssuesEvent
+		BeforeEach(func() {
+			event = &github.IssuesEvent{
+				Issue: &github.Issue{
+					Number:    github.Int(1),
+					Labels:    []*github.Label{{Name: github.String("kind/bug")}},
+					Milestone: &github.Milestone{Title: github.String("v1.27.0")},
+				},
+			}
+		})
+		It("should match the added label", func() {
+			event.Action = github.String("labeled")
+			event.Label = &github.Label{Name: github.String("jira/clone")}
+			Expect(Rule{Action: "labeled", Pattern: "jira/*"}.Match(event)).To(BeTrue())
+			Expect(Rule{Action: "labeled", Pattern: "kind/bug"}.Match(event)).To(BeFalse())
+		})
+		It("should match the milestone title", func() {
+			event.Action = github.String("milestoned")
+			Expect(Rule{Action: "milestoned", Pattern: "v1.*"}.Match(event)).To(BeTrue())
+			Expect(Rule{Action: "milestoned", Pattern: "v2.*"}.Match(event)).To(BeFalse())
+			Expect(Rule{Action: "labeled", Pattern: "*"}.Match(event)).To(BeFalse())
+		})
+		It("should match the labels of opened issues", func() {
+			event.Action = github.String("opened")
+			Expect(Rule{Action: "opened"}.Match(event)).To(BeTrue())
+			Expect(Rule{Action: "opened", Pattern: "kind/*"}.Match(event)).To(BeTrue())
+			Expect(Rule{Action: "opened", Pattern: "security"}.Match(event)).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,205 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sync"
+
+	"github.com/google/go-github/v47/github"
+
+	"github.com/jmrodri/gh2jira/internal/redact"
+)
+
+const (
+	// maxPayloadSize is the largest payload Github delivers
+	maxPayloadSize = 25 << 20
+	// queueSize is how many matched issues can wait to be cloned
+	queueSize = 100
+)
+
+// CloneFunc clones the given issue. It is called for every issues event that
+// matches one of the rules, one issue at a time.
+type CloneFunc func(issue *github.Issue) error
+
+// Handler receives Github issues webhook events on /webhook and exposes a
+// health check on /healthz.
+type Handler struct {
+	secret []byte
+	rules  []Rule
+	clone  CloneFunc
+	mux    *http.ServeMux
+
+	// Github gives up on a delivery after 10 seconds, so issues are cloned
+	// by a worker after the event is acknowledged
+	mu     sync.RWMutex
+	closed bool
+	queue  chan job
+	done   chan struct{}
+}
+
+// job is an issue waiting to be cloned.
+type job struct {
+	repo  string
+	issue *github.Issue
+}
+
+// NewHandler returns a Handler that verifies events with the given webhook
+// secret and calls clone for issues that match any of the rules. Close it to
+// wait for the issues still queued.
+func NewHandler(secret []byte, rules []Rule, clone CloneFunc) (*Handler, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("a webhook secret is required")
+	}
+	if len(rules) == 0 {
+		return nil, errors.New("at least one rule is required")
+	}
+
+	h := &Handler{
+		secret: secret,
+		rules:  rules,
+		clone:  clone,
+		mux:    http.NewServeMux(),
+		queue:  make(chan job, queueSize),
+		done:   make(chan struct{}),
+	}
+	go h.work()
+	h.mux.HandleFunc("/webhook", h.handleWebhook)
+	h.mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok\n"))
+	})
+	return h, nil
+}
+
+// Close stops accepting events and waits until the queued issues are cloned.
+func (h *Handler) Close() {
+	h.mu.Lock()
+	if !h.closed {
+		h.closed = true
+		close(h.queue)
+	}
+	h.mu.Unlock()
+	<-h.done
+}
+
+// enqueue queues the issue for cloning.
+func (h *Handler) enqueue(j job) error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.closed {
+		return errors.New("the server is shutting down")
+	}
+	select {
+	case h.queue <- j:
+		return nil
+	default:
+		return errors.New("the clone queue is full")
+	}
+}
+
+// work clones the queued issues one after the other.
+func (h *Handler) work() {
+	defer close(h.done)
+	for j := range h.queue {
+		if err := h.clone(j.issue); err != nil {
+			fmt.Fprintf(redact.Stdout, "Error cloning issue %s#%d: %v\n", j.repo, j.issue.GetNumber(), err)
+		}
+	}
+}
+
+// ServeHTTP implementation of `http.Handler`
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// github keeps the responses in its delivery log, so the details of
+	// errors only go to our log
+	payload, err := h.validate(r)
+	if err != nil {
+		fmt.Fprintf(redact.Stdout, "Rejected delivery %s: %v\n", github.DeliveryID(r), err)
+		http.Error(w, "invalid signature or payload", http.StatusUnauthorized)
+		return
+	}
+
+	eventType := github.WebHookType(r)
+	switch eventType {
+	case "ping":
+		w.Write([]byte("pong\n"))
+		return
+	case "issues":
+	default:
+		fmt.Fprintf(w, "ignoring %s event\n", eventType)
+		return
+	}
+
+	e, err := github.ParseWebHook(eventType, payload)
+	if err != nil {
+		fmt.Fprintf(redact.Stdout, "Invalid %s event %s: %v\n", eventType, github.DeliveryID(r), err)
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+	event := e.(*github.IssuesEvent)
+
+	rule, ok := matchRules(h.rules, event)
+	if !ok {
+		fmt.Fprintf(w, "no rule matched %s of issue #%d\n",
+			event.GetAction(), event.GetIssue().GetNumber())
+		return
+	}
+
+	fmt.Fprintf(redact.Stdout, "Rule %s matched %s of %s#%d\n", rule, event.GetAction(),
+		event.GetRepo().GetFullName(), event.GetIssue().GetNumber())
+	if err := h.enqueue(job{repo: event.GetRepo().GetFullName(), issue: event.GetIssue()}); err != nil {
+		fmt.Fprintf(redact.Stdout, "Dropped issue %s#%d, %v\n",
+			event.GetRepo().GetFullName(), event.GetIssue().GetNumber(), err)
+		http.Error(w, "unable to clone issues now, try again later", http.StatusServiceUnavailable)
+		return
+	}
+	// the outcome of the clone is only in our log
+	w.WriteHeader(http.StatusAccepted)
+	fmt.Fprintf(w, "rule %s matched, issue #%d queued\n", rule, event.GetIssue().GetNumber())
+}
+
+// validate reads the payload and verifies its X-Hub-Signature-256 HMAC.
+func (h *Handler) validate(r *http.Request) ([]byte, error) {
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || contentType != "application/json" {
+		return nil, fmt.Errorf("unsupported content type %q", r.Header.Get("Content-Type"))
+	}
+
+	signature := r.Header.Get(github.SHA256SignatureHeader)
+	if signature == "" {
+		return nil, fmt.Errorf("missing %s header", github.SHA256SignatureHeader)
+	}
+
+	payload, err := io.ReadAll(io.LimitReader(r.Body, maxPayloadSize))
+	if err != nil {
+		return nil, err
+	}
+	if err := github.ValidateSignature(signature, payload, h.secret); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
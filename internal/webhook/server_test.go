@@ -0,0 +1,191 @@
+// Copyright © 2022 jesus m. rodriguez jmrodri@gmail.com
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	"github.com/google/go-github/v47/github"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var secret = []byte("it's a secret")
+
+func sign(payload []byte, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func newRequest(event string, payload []byte, signature string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(payload))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set(github.EventTypeHeader, event)
+	if signature != "" {
+		r.Header.Set(github.SHA256SignatureHeader, signature)
+	}
+	return r
+}
+
+var _ = Describe("Server", func() {
+	var (
+		handler *Handler
+		cloned  []int
+		payload []byte
+	)
+	BeforeEach(func() {
+		cloned = nil
+		var err error
+		handler, err = NewHandler(secret,
+			[]Rule{{Action: "labeled", Pattern: "jira/clone"}},
+			func(issue *github.Issue) error {
+				if issue.GetNumber() == 666 {
+					return errors.New("jira is down, Authorization: Bearer s3cret-jira-token")
+				}
+				cloned = append(cloned, issue.GetNumber())
+				return nil
+			})
+		Expect(err).NotTo(HaveOccurred())
+
+		payload = []byte(`{"action": "labeled", "label": {"name": "jira/clone"},
+			"issue": {"number": 42, "title": "Issue 42"},
+			"repository": {"full_name": "foo/bar"}}`)
+	})
+	AfterEach(func() {
+		handler.Close()
+	})
+
+	Describe("NewHandler", func() {
+		It("should require a secret", func() {
+			_, err := NewHandler(nil, []Rule{{Action: "opened"}}, nil)
+			Expect(err).To(HaveOccurred())
+		})
+		It("should require rules", func() {
+			_, err := NewHandler(secret, nil, nil)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	It("should report healthy", func() {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		Expect(w.Code).To(Equal(http.StatusOK))
+	})
+
+	It("should clone issues matching a rule after acknowledging them", func() {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newRequest("issues", payload, sign(payload, secret)))
+		Expect(w.Code).To(Equal(http.StatusAccepted))
+		Expect(w.Body.String()).To(Equal("rule labeled=jira/clone matched, issue #42 queued\n"))
+		handler.Close()
+		Expect(cloned).To(Equal([]int{42}))
+	})
+
+	It("should refuse events once closed", func() {
+		handler.Close()
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newRequest("issues", payload, sign(payload, secret)))
+		Expect(w.Code).To(Equal(http.StatusServiceUnavailable))
+		Expect(cloned).To(BeEmpty())
+	})
+
+	It("should ignore issues not matching any rule", func() {
+		payload = []byte(`{"action": "labeled", "label": {"name": "kind/bug"},
+			"issue": {"number": 42}}`)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newRequest("issues", payload, sign(payload, secret)))
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).To(ContainSubstring("no rule matched"))
+		Expect(cloned).To(BeEmpty())
+	})
+
+	It("should ignore other events", func() {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newRequest("push", payload, sign(payload, secret)))
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(cloned).To(BeEmpty())
+	})
+
+	It("should answer pings", func() {
+		ping := []byte(`{"zen": "Keep it logically awesome."}`)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newRequest("ping", ping, sign(ping, secret)))
+		Expect(w.Code).To(Equal(http.StatusOK))
+		Expect(w.Body.String()).To(Equal("pong\n"))
+	})
+
+	It("should reject payloads without a signature", func() {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newRequest("issues", payload, ""))
+		Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		Expect(cloned).To(BeEmpty())
+	})
+
+	It("should reject payloads signed with another secret", func() {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newRequest("issues", payload, sign(payload, []byte("wrong"))))
+		Expect(w.Code).To(Equal(http.StatusUnauthorized))
+		Expect(cloned).To(BeEmpty())
+	})
+
+	It("should reject tampered payloads", func() {
+		signature := sign(payload, secret)
+		tampered := bytes.Replace(payload, []byte("42"), []byte("43"), 1)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, newRequest("issues", tampered, signature))
+		Expect(w.Code).To(Equal(http.StatusUnauthorized))
+	})
+
+	It("should only accept POST", func() {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/webhook", nil))
+		Expect(w.Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+
+	It("should log clone failures", func() {
+		payload = []byte(`{"action": "labeled", "label": {"name": "jira/clone"},
+			"issue": {"number": 666}, "repository": {"full_name": "foo/bar"}}`)
+
+		// Capture stdout to verify the log
+		r, pw, _ := os.Pipe()
+		tmp := os.Stdout
+		defer func() {
+			os.Stdout = tmp
+		}()
+		os.Stdout = pw
+		codes := make(chan int, 1)
+		go func() {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, newRequest("issues", payload, sign(payload, secret)))
+			codes <- w.Code
+			handler.Close()
+			pw.Close()
+		}()
+		stdout, _ := io.ReadAll(r)
+
+		Expect(<-codes).To(Equal(http.StatusAccepted))
+		Expect(string(stdout)).To(ContainSubstring("Error cloning issue foo/bar#666: jira is down"))
+	})
+})